@@ -0,0 +1,160 @@
+package qrcode
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"io"
+	"math"
+	"time"
+)
+
+// pngSignature starts every PNG (and APNG) file
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+// GenerateAPNG generates a full-color animated PNG with one frame per entry of frames, each
+// shown for delayMs milliseconds and looping forever. Frames with an empty Data or a zero Size
+// take them from opts; all frames must render to the same dimensions. With no frames, opts
+// itself is rendered as a single-frame animation. Viewers without APNG support show the first frame
+func (g *Generator) GenerateAPNG(opts Options, frames []Options, delayMs int) ([]byte, error) {
+	if delayMs < 0 || delayMs > math.MaxUint16 {
+		return nil, fmt.Errorf("frame delay must be between 0 and %d ms", math.MaxUint16)
+	}
+	if len(frames) == 0 {
+		frames = []Options{opts}
+	}
+
+	images := make([]*image.NRGBA, len(frames))
+	for i, frameOpts := range frames {
+		if frameOpts.Data == "" {
+			frameOpts.Data = opts.Data
+		}
+		if frameOpts.Size == 0 {
+			frameOpts.Size = opts.Size
+		}
+		img, _, err := g.render(frameOpts, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("failed to render frame %d: %w", i, err)
+		}
+		if i > 0 && img.Bounds().Size() != images[0].Bounds().Size() {
+			return nil, fmt.Errorf("frame %d size %v differs from first frame size %v", i, img.Bounds().Size(), images[0].Bounds().Size())
+		}
+		nrgba := image.NewNRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+		draw.Draw(nrgba, nrgba.Bounds(), img, img.Bounds().Min, draw.Src)
+		images[i] = nrgba
+	}
+
+	var out bytes.Buffer
+	if err := encodeAPNG(&out, images, uint16(delayMs)); err != nil {
+		return nil, fmt.Errorf("failed to encode apng: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// GenerateAPNG is a convenience function that creates a generator and generates an animated PNG
+func GenerateAPNG(opts Options, frames []Options, delayMs int) ([]byte, error) {
+	g := New()
+	return g.GenerateAPNG(opts, frames, delayMs)
+}
+
+// encodeAPNG writes frames of identical size as an 8-bit RGBA animated PNG
+func encodeAPNG(w io.Writer, frames []*image.NRGBA, delayMs uint16) error {
+	width, height := frames[0].Bounds().Dx(), frames[0].Bounds().Dy()
+	if _, err := w.Write(pngSignature); err != nil {
+		return err
+	}
+
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(width))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(height))
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 6 // color type: truecolor with alpha
+	if err := writeChunk(w, "IHDR", ihdr); err != nil {
+		return err
+	}
+
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:], uint32(len(frames)))
+	// num_plays stays 0: loop forever
+	if err := writeChunk(w, "acTL", actl); err != nil {
+		return err
+	}
+
+	var seq uint32
+	for i, frame := range frames {
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], seq)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(width))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(height))
+		// x/y offsets stay 0, dispose_op and blend_op stay 0 (none/source)
+		binary.BigEndian.PutUint16(fctl[20:], delayMs)
+		binary.BigEndian.PutUint16(fctl[22:], 1000)
+		if err := writeChunk(w, "fcTL", fctl); err != nil {
+			return err
+		}
+		seq++
+
+		data, err := compressFrame(frame)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			err = writeChunk(w, "IDAT", data)
+		} else {
+			fdat := make([]byte, 4, 4+len(data))
+			binary.BigEndian.PutUint32(fdat, seq)
+			err = writeChunk(w, "fdAT", append(fdat, data...))
+			seq++
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeChunk(w, "IEND", nil)
+}
+
+// compressFrame zlib-compresses the image rows, each prefixed with filter type 0 (none)
+func compressFrame(img *image.NRGBA) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := zlib.NewWriterLevel(&buf, zlib.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	rowLen := img.Bounds().Dx() * 4
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+rowLen]
+		if _, err := zw.Write([]byte{0}); err != nil {
+			return nil, err
+		}
+		if _, err := zw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeChunk writes a PNG chunk: length, type, data and CRC over type and data
+func writeChunk(w io.Writer, chunkType string, data []byte) error {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header[0:], uint32(len(data)))
+	copy(header[4:], chunkType)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	footer := binary.BigEndian.AppendUint32(nil, crc.Sum32())
+
+	for _, b := range [][]byte{header, data, footer} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package qrcode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// apngChunks returns the chunk types of a PNG stream in order
+func apngChunks(t *testing.T, data []byte) []string {
+	t.Helper()
+	if !bytes.HasPrefix(data, pngSignature) {
+		t.Fatal("missing PNG signature")
+	}
+	var chunks []string
+	for rest := data[len(pngSignature):]; len(rest) > 0; {
+		if len(rest) < 12 {
+			t.Fatal("truncated chunk")
+		}
+		n := int(binary.BigEndian.Uint32(rest))
+		chunks = append(chunks, string(rest[4:8]))
+		rest = rest[12+n:]
+	}
+	return chunks
+}
+
+func TestGenerateAPNG(t *testing.T) {
+	base := Options{
+		Data:       "https://example.com",
+		Size:       250,
+		Background: "white",
+	}
+	frames := []Options{
+		{Foreground: "red", Background: "white"},
+		{Foreground: "green", Background: "white"},
+		{Foreground: "blue", Background: "white"},
+	}
+
+	data, err := GenerateAPNG(base, frames, 200)
+	if err != nil {
+		t.Fatalf("GenerateAPNG() error = %v", err)
+	}
+
+	chunks := apngChunks(t, data)
+	want := []string{"IHDR", "acTL", "fcTL", "IDAT", "fcTL", "fdAT", "fcTL", "fdAT", "IEND"}
+	if len(chunks) != len(want) {
+		t.Fatalf("chunks = %v, want %v", chunks, want)
+	}
+	for i := range want {
+		if chunks[i] != want[i] {
+			t.Fatalf("chunks = %v, want %v", chunks, want)
+		}
+	}
+
+	// Decoders without APNG support see the first frame
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("GenerateAPNG() returned invalid PNG: %v", err)
+	}
+	if img.Bounds().Dx() != 250 || img.Bounds().Dy() != 250 {
+		t.Errorf("image bounds = %v, want 250x250", img.Bounds())
+	}
+	if got := color.RGBAModel.Convert(img.At(0, 0)).(color.RGBA); got != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("first frame corner = %v, want red", got)
+	}
+}
+
+func TestGenerateAPNG_Errors(t *testing.T) {
+	base := Options{Data: "https://example.com", Size: 250}
+	tests := []struct {
+		name   string
+		frames []Options
+		delay  int
+	}{
+		{"negative delay", nil, -1},
+		{"delay too long", nil, 70000},
+		{"mismatched sizes", []Options{{}, {Size: 300}}, 100},
+		{"invalid frame", []Options{{Data: "ok"}, {MaxVersion: 41}}, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := GenerateAPNG(base, tt.frames, tt.delay); err == nil {
+				t.Error("GenerateAPNG() expected an error")
+			}
+		})
+	}
+
+	if _, err := GenerateAPNG(base, nil, 100); err != nil {
+		t.Errorf("GenerateAPNG() without frames error = %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package qrcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/disintegration/imaging"
+	"golang.org/x/image/font"
+)
+
+// Banner is a strip drawn to the right of the code, e.g. an app icon and name on app install
+// codes. It spans the height of the code (and its frame) and leaves the quiet zone intact
+type Banner struct {
+	// Icon is drawn at the left of the banner, fitted to a square box. Optional
+	Icon image.Image
+
+	// Text is drawn right of the icon with CaptionFont, shortened with "..." when it does not fit
+	Text string
+
+	// TextColor is the color of the text (default: foreground)
+	TextColor string
+
+	// Background is the fill color of the banner (default: background)
+	Background string
+
+	// Width is the width of the banner in pixels (default: the height of the code)
+	Width int
+}
+
+// bannerLayout holds the pixel dimensions of a banner beside a code of a given height
+type bannerLayout struct {
+	// width is the banner width, pad the margin around the icon and text
+	width, pad int
+	// icon is the side of the icon box, label the height of the text line
+	icon, label int
+}
+
+// newBannerLayout scales b to a code of height pixels
+func newBannerLayout(b *Banner, height int) (bannerLayout, error) {
+	if b.Icon == nil && b.Text == "" {
+		return bannerLayout{}, fmt.Errorf("banner requires an icon or text")
+	}
+	if b.Width < 0 {
+		return bannerLayout{}, fmt.Errorf("banner width must not be negative")
+	}
+	l := bannerLayout{
+		width: b.Width,
+		pad:   max(4, height/12),
+		label: max(24, height/6),
+	}
+	if l.width == 0 {
+		l.width = height
+	}
+	if b.Icon != nil {
+		l.icon = max(1, min(height-2*l.pad, l.width/3))
+	}
+	return l, nil
+}
+
+// drawBanner extends img to the right with the banner b, drawing its icon vertically centered
+// at the left and its text centered in the remaining space
+func drawBanner(img image.Image, b *Banner, face font.Face, fg, bg color.Color) (*image.RGBA, error) {
+	code := img.Bounds()
+	l, err := newBannerLayout(b, code.Dy())
+	if err != nil {
+		return nil, err
+	}
+	textColor, fill := fg, bg
+	if b.TextColor != "" {
+		textColor = parseColor(b.TextColor)
+	}
+	if b.Background != "" {
+		fill = parseColor(b.Background)
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, code.Dx()+l.width, code.Dy()))
+	draw.Draw(canvas, code.Sub(code.Min), img, code.Min, draw.Src)
+	strip := image.Rect(code.Dx(), 0, canvas.Bounds().Dx(), code.Dy())
+	draw.Draw(canvas, strip, image.NewUniform(fill), image.Point{}, draw.Src)
+
+	textLeft := strip.Min.X + l.pad
+	if b.Icon != nil {
+		icon := imaging.Fit(b.Icon, l.icon, l.icon, imaging.Lanczos)
+		size := icon.Bounds().Size()
+		at := image.Pt(textLeft+(l.icon-size.X)/2, (code.Dy()-size.Y)/2)
+		draw.Draw(canvas, image.Rectangle{Min: at, Max: at.Add(size)}, icon, image.Point{}, draw.Over)
+		textLeft += l.icon + l.pad
+	}
+	if right := strip.Max.X - l.pad; b.Text != "" && textLeft < right {
+		text := image.Rect(textLeft, 0, right, code.Dy())
+		drawLabel(canvas, text, ellipsize(b.Text, face, text.Dx()), face, textColor)
+	}
+	return canvas, nil
+}
@@ -0,0 +1,97 @@
+package qrcode
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestGenerateImage_Banner(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	gray := color.RGBA{R: 200, G: 200, B: 200, A: 255}
+	icon := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	draw.Draw(icon, icon.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+
+	base := Options{
+		Data:       "https://example.com",
+		Size:       250,
+		Foreground: "black",
+		Background: "white",
+		Border:     4,
+	}
+	code, err := GenerateImage(base)
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+
+	opts := base
+	opts.Banner = &Banner{Icon: icon, Text: "My App", TextColor: "blue", Background: "rgb(200,200,200)"}
+	img, err := GenerateImage(opts)
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+	bounds, err := New().OutputBounds(opts)
+	if err != nil {
+		t.Fatalf("OutputBounds() error = %v", err)
+	}
+	if img.Bounds() != bounds || bounds != image.Rect(0, 0, 500, 250) {
+		t.Fatalf("image bounds = %v, OutputBounds() = %v, want 500x250", img.Bounds(), bounds)
+	}
+
+	for y := 0; y < 250; y++ {
+		for x := 0; x < 250; x++ {
+			if got, want := color.RGBAModel.Convert(img.At(x, y)), color.RGBAModel.Convert(code.At(x, y)); got != want {
+				t.Fatalf("code pixel (%d,%d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+
+	// The 83px icon box starts after the 20px padding, the text after the icon and another pad
+	tests := []struct {
+		name string
+		x, y int
+		want color.RGBA
+	}{
+		{"icon", 250 + 20 + 41, 125, red},
+		{"banner background", 499, 0, gray},
+	}
+	for _, tt := range tests {
+		if got := color.RGBAModel.Convert(img.At(tt.x, tt.y)).(color.RGBA); got != tt.want {
+			t.Errorf("%s: pixel (%d,%d) = %v, want %v", tt.name, tt.x, tt.y, got, tt.want)
+		}
+	}
+	var textPixels int
+	for y := 0; y < 250; y++ {
+		for x := 250 + 20 + 83 + 20; x < 500; x++ {
+			if color.RGBAModel.Convert(img.At(x, y)).(color.RGBA) == blue {
+				textPixels++
+			}
+		}
+	}
+	if textPixels == 0 {
+		t.Error("banner text was not drawn")
+	}
+
+	captioned := opts
+	captioned.Caption = "Get the app"
+	bounds, err = New().OutputBounds(captioned)
+	if err != nil {
+		t.Fatalf("OutputBounds() error = %v", err)
+	}
+	if want := image.Rect(0, 0, 500, 250+defaultCaptionHeight); bounds != want {
+		t.Errorf("OutputBounds() with caption = %v, want %v", bounds, want)
+	}
+
+	for name, banner := range map[string]*Banner{
+		"empty banner":   {},
+		"negative width": {Text: "My App", Width: -1},
+	} {
+		invalid := base
+		invalid.Banner = banner
+		if _, err := GenerateImage(invalid); err == nil {
+			t.Errorf("GenerateImage() with %s should fail", name)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package qrcode
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// BatchOptions configures GenerateBatch
+type BatchOptions struct {
+	// Workers is the number of codes rendered concurrently (default: number of CPUs)
+	Workers int
+
+	// Dedup renders items with identical effective options once and returns the same PNG
+	// bytes for all of them, which must then not be modified. Items with a PayloadWrapper
+	// are always rendered individually, as functions cannot be compared
+	Dedup bool
+
+	// Progress, when set, is called after each item completes with the number of items done
+	// so far and the batch size. Calls are serialized, never concurrent; items sharing a
+	// render through Dedup complete together
+	Progress func(done, total int)
+}
+
+// BatchResult is the outcome of generating one item of a batch
+type BatchResult struct {
+	// PNG is the generated image, nil if generation failed
+	PNG []byte
+
+	// Err is the reason generation failed
+	Err error
+}
+
+// GenerateBatch generates a PNG for each item concurrently. Results are returned in item order;
+// a failing item does not stop the others
+func (g *Generator) GenerateBatch(items []Options, batch BatchOptions) []BatchResult {
+	results := make([]BatchResult, len(items))
+
+	// owner[i] is the index of the item rendered on behalf of item i; copies[i] is the number
+	// of items rendered by item i
+	owner := make([]int, len(items))
+	copies := make([]int, len(items))
+	var unique []int
+	seen := make(map[[sha256.Size]byte]int)
+	for i, opts := range items {
+		owner[i] = i
+		if batch.Dedup && opts.PayloadWrapper == nil {
+			key := optionsKey(opts)
+			if first, ok := seen[key]; ok {
+				owner[i] = first
+				copies[first]++
+				continue
+			}
+			seen[key] = i
+		}
+		copies[i]++
+		unique = append(unique, i)
+	}
+
+	var progressMu sync.Mutex
+	done := 0
+	workers := batch.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, len(unique)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				data, err := g.GeneratePNG(items[i])
+				results[i] = BatchResult{PNG: data, Err: err}
+				if batch.Progress != nil {
+					progressMu.Lock()
+					done += copies[i]
+					batch.Progress(done, len(items))
+					progressMu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, i := range unique {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	for i := range results {
+		results[i] = results[owner[i]]
+	}
+	return results
+}
+
+// GenerateBatch is a convenience function that creates a generator and generates a batch of QR codes
+func GenerateBatch(items []Options, batch BatchOptions) []BatchResult {
+	g := New()
+	return g.GenerateBatch(items, batch)
+}
+
+// GenerateBatchUniform generates a batch like GenerateBatch, but renders every code with the same
+// module size in pixels, so codes of different versions look consistent side by side while their
+// overall dimensions differ. The module size is the largest whole number of pixels at which
+// every code fits within its Size; each code is then exactly that many pixels per module of
+// its symbol and quiet zone. Items with ForceExactSize or PhysicalSize fail, as they fix the
+// code size instead, as do items whose Border is wider than their code at that module size
+func (g *Generator) GenerateBatchUniform(items []Options, batch BatchOptions) []BatchResult {
+	scaled := make([]Options, len(items))
+	modules := make([]int, len(items))
+	rejected := make(map[int]error)
+	modulePx := 0
+	for i, item := range items {
+		scaled[i] = item
+		opts := g.withDefaults(item)
+		if opts.ForceExactSize || opts.PhysicalSize != (PhysicalSize{}) {
+			rejected[i] = fmt.Errorf("uniform module size cannot be combined with force exact size or physical size")
+			continue
+		}
+		if err := g.shorten(&opts); err != nil {
+			rejected[i] = err
+			continue
+		}
+		scaled[i].Data, scaled[i].shortened = opts.Data, true
+		qr, err := prepare(&opts)
+		if err != nil {
+			// Rendered unchanged, the item fails again with this error
+			continue
+		}
+		modules[i] = totalModules(qr)
+		if px := max(1, opts.Size/modules[i]); modulePx == 0 || px < modulePx {
+			modulePx = px
+		}
+	}
+	for i, n := range modules {
+		if n == 0 {
+			continue
+		}
+		// prepare adds the border padding back
+		padding := borderPadding(g.withDefaults(items[i]))
+		if modulePx*n <= padding {
+			rejected[i] = fmt.Errorf("border padding of %d pixels exceeds the code size of %d pixels at the uniform module size of %d pixels", padding, modulePx*n, modulePx)
+			continue
+		}
+		scaled[i].Size = modulePx*n - padding
+	}
+
+	results := g.GenerateBatch(scaled, batch)
+	for i, err := range rejected {
+		results[i] = BatchResult{Err: err}
+	}
+	return results
+}
+
+// GenerateBatchUniform is a convenience function that creates a generator and generates a batch
+// of QR codes with a uniform module size
+func GenerateBatchUniform(items []Options, batch BatchOptions) []BatchResult {
+	g := New()
+	return g.GenerateBatchUniform(items, batch)
+}
+
+// optionsKey hashes the effective options, so items differing only in unset defaults match
+func optionsKey(opts Options) [sha256.Size]byte {
+	applyDefaults(&opts)
+	return sha256.Sum256([]byte(fmt.Sprintf("%#v", opts)))
+}
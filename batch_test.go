@@ -0,0 +1,152 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGenerateBatch(t *testing.T) {
+	items := []Options{
+		{Data: "https://example.com/a"},
+		{Data: "https://example.com/b", Size: 200},
+		{Data: "https://example.com/a", Size: 300}, // same as the first after defaults
+		{Data: ""},
+		{Data: "https://example.com/b", Size: 200},
+	}
+
+	tests := []struct {
+		name         string
+		dedup        bool
+		wantShared   bool
+		wantProgress int
+	}{
+		{name: "without dedup", dedup: false, wantShared: false, wantProgress: 5},
+		{name: "with dedup", dedup: true, wantShared: true, wantProgress: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New()
+			var progress []int
+			var active atomic.Int32
+			results := g.GenerateBatch(items, BatchOptions{
+				Workers: 2,
+				Dedup:   tt.dedup,
+				Progress: func(done, total int) {
+					if active.Add(1) != 1 {
+						t.Error("progress called concurrently")
+					}
+					if total != len(items) {
+						t.Errorf("progress total = %d, want %d", total, len(items))
+					}
+					progress = append(progress, done)
+					time.Sleep(time.Millisecond)
+					active.Add(-1)
+				},
+			})
+			if len(results) != len(items) {
+				t.Fatalf("GenerateBatch() returned %d results, want %d", len(results), len(items))
+			}
+
+			for i, r := range results {
+				if wantErr := items[i].Data == ""; (r.Err != nil) != wantErr {
+					t.Errorf("item %d error = %v, wantErr %v", i, r.Err, wantErr)
+				}
+			}
+			if !bytes.Equal(results[0].PNG, results[2].PNG) || !bytes.Equal(results[1].PNG, results[4].PNG) {
+				t.Error("identical items should produce identical PNGs")
+			}
+			if bytes.Equal(results[0].PNG, results[1].PNG) {
+				t.Error("different items should produce different PNGs")
+			}
+
+			if len(progress) != tt.wantProgress || progress[len(progress)-1] != len(items) {
+				t.Errorf("progress = %v, want %d calls ending at %d", progress, tt.wantProgress, len(items))
+			}
+			for i := 1; i < len(progress); i++ {
+				if progress[i] <= progress[i-1] {
+					t.Errorf("progress = %v, want increasing counts", progress)
+				}
+			}
+
+			shared := &results[0].PNG[0] == &results[2].PNG[0]
+			if shared != tt.wantShared {
+				t.Errorf("results shared = %v, want %v", shared, tt.wantShared)
+			}
+			wantGenerated := uint64(4)
+			if tt.dedup {
+				wantGenerated = 2
+			}
+			if got := g.Metrics().CodesGenerated; got != wantGenerated {
+				t.Errorf("CodesGenerated = %d, want %d", got, wantGenerated)
+			}
+		})
+	}
+}
+
+func TestGenerateBatchUniform(t *testing.T) {
+	items := []Options{
+		{Data: "a", Size: 300, Border: 4},
+		{Data: "https://example.com/" + strings.Repeat("x", 60), Size: 300, Border: 4},
+		{Data: "https://example.com", Size: 500},
+		{Data: ""},
+		{Data: "https://example.com", ForceExactSize: true},
+	}
+	results := GenerateBatchUniform(items, BatchOptions{Workers: 2})
+	if len(results) != len(items) {
+		t.Fatalf("GenerateBatchUniform() returned %d results, want %d", len(results), len(items))
+	}
+	for _, i := range []int{3, 4} {
+		if results[i].Err == nil {
+			t.Errorf("item %d should fail", i)
+		}
+	}
+
+	// The densest code at 300 pixels sets the module size for all of them
+	modules := make([]int, 3)
+	modulePx := 0
+	for i := range modules {
+		_, info, err := GenerateWithInfo(items[i])
+		if err != nil {
+			t.Fatalf("GenerateWithInfo() error = %v", err)
+		}
+		modules[i] = info.Modules
+		if items[i].Border != 0 {
+			modules[i] += 2 * quietZoneModules
+		}
+		if px := items[i].Size / modules[i]; modulePx == 0 || px < modulePx {
+			modulePx = px
+		}
+	}
+	for i, n := range modules {
+		if results[i].Err != nil {
+			t.Fatalf("item %d error = %v", i, results[i].Err)
+		}
+		img, err := png.Decode(bytes.NewReader(results[i].PNG))
+		if err != nil {
+			t.Fatalf("item %d is not a valid PNG: %v", i, err)
+		}
+		if got, want := img.Bounds().Dx(), modulePx*n; got != want {
+			t.Errorf("item %d width = %d, want %d modules of %d pixels", i, got, n, modulePx)
+		}
+	}
+}
+
+func TestGenerateBatchUniform_WideBorder(t *testing.T) {
+	// The first code sets a module size of 60 / 29 = 2 pixels, so the second code spans
+	// 2 * 33 = 66 pixels, less than the 192 pixels of padding its border adds
+	items := []Options{
+		{Data: "a", Size: 60, Border: 4},
+		{Data: "https://example.com", Size: 300, Border: 100},
+	}
+	results := GenerateBatchUniform(items, BatchOptions{})
+	if results[0].Err != nil {
+		t.Fatalf("item 0 error = %v", results[0].Err)
+	}
+	if err := results[1].Err; err == nil || !strings.Contains(err.Error(), "border padding") {
+		t.Errorf("item 1 error = %v, want a border padding error", err)
+	}
+}
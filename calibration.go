@@ -0,0 +1,35 @@
+package qrcode
+
+import "fmt"
+
+// CalibrationData is the fixed string encoded by CalibrationSet codes
+const CalibrationData = "QRCODE-CALIBRATION-0123456789"
+
+// CalibrationSet generates a black on white calibration code encoding CalibrationData at error
+// level H with a standard quiet zone for each of sizes, keyed by size. Scanners can be checked
+// against these fixtures by comparing the decoded text with CalibrationData
+func CalibrationSet(sizes []int) (map[int][]byte, error) {
+	g := New()
+	set := make(map[int][]byte, len(sizes))
+	for _, size := range sizes {
+		if size <= 0 {
+			return nil, fmt.Errorf("invalid calibration size %d: must be positive", size)
+		}
+		if _, ok := set[size]; ok {
+			continue
+		}
+		data, err := g.GeneratePNG(Options{
+			Data:       CalibrationData,
+			Size:       size,
+			Foreground: "black",
+			Background: "white",
+			Error:      "H",
+			Border:     quietZoneModules,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate %dpx calibration code: %w", size, err)
+		}
+		set[size] = data
+	}
+	return set, nil
+}
@@ -0,0 +1,45 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+)
+
+func TestCalibrationSet(t *testing.T) {
+	set, err := CalibrationSet([]int{100, 300, 300, 600})
+	if err != nil {
+		t.Fatalf("CalibrationSet() error = %v", err)
+	}
+	if len(set) != 3 {
+		t.Fatalf("CalibrationSet() returned %d codes, want 3", len(set))
+	}
+	for size, data := range set {
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("CalibrationSet() returned invalid PNG for size %d: %v", size, err)
+		}
+		if got := img.Bounds().Dx(); got != size {
+			t.Errorf("calibration code width = %d, want %d", got, size)
+		}
+	}
+
+	want, err := GeneratePNG(Options{
+		Data:       CalibrationData,
+		Size:       300,
+		Foreground: "black",
+		Background: "white",
+		Error:      "H",
+		Border:     4,
+	})
+	if err != nil {
+		t.Fatalf("GeneratePNG() error = %v", err)
+	}
+	if !bytes.Equal(set[300], want) {
+		t.Error("calibration code should encode CalibrationData at error level H")
+	}
+
+	if _, err := CalibrationSet([]int{300, 0}); err == nil {
+		t.Error("CalibrationSet() with a non-positive size should fail")
+	}
+}
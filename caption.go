@@ -0,0 +1,86 @@
+package qrcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// defaultCaptionHeight is the height in pixels of the caption strip when CaptionHeight is unset
+const defaultCaptionHeight = 40
+
+// captionFontScale is the caption font size relative to the caption strip height
+const captionFontScale = 0.5
+
+// captionPadding is the minimum horizontal margin in pixels around a shortened caption
+const captionPadding = 4
+
+// captionFace returns a face for the TTF/OTF font data sized to the caption strip,
+// or the built-in basicfont face when no font data is supplied
+func captionFace(fontData []byte, height int) (font.Face, error) {
+	if len(fontData) == 0 {
+		return basicfont.Face7x13, nil
+	}
+	f, err := opentype.Parse(fontData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse caption font: %w", err)
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    float64(height) * captionFontScale,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create caption font face: %w", err)
+	}
+	return face, nil
+}
+
+// drawCaption extends img downward by height pixels filled with bg and draws text centered
+// in the new strip, leaving the code and its quiet zone untouched
+func drawCaption(img image.Image, text string, height int, face font.Face, fg, bg color.Color) *image.RGBA {
+	bounds := img.Bounds()
+	canvas := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()+height))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{C: bg}, image.Point{}, draw.Src)
+	draw.Draw(canvas, image.Rect(0, 0, bounds.Dx(), bounds.Dy()), img, bounds.Min, draw.Src)
+	drawLabel(canvas, image.Rect(0, bounds.Dy(), bounds.Dx(), bounds.Dy()+height), text, face, fg)
+	return canvas
+}
+
+// ellipsize shortens text with a trailing "..." until it is at most width pixels wide in face
+func ellipsize(text string, face font.Face, width int) string {
+	limit := fixed.I(width)
+	if font.MeasureString(face, text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	for n := len(runes) - 1; n > 0; n-- {
+		if short := string(runes[:n]) + "..."; font.MeasureString(face, short) <= limit {
+			return short
+		}
+	}
+	return "..."
+}
+
+// drawLabel draws text centered in rect
+func drawLabel(dst draw.Image, rect image.Rectangle, text string, face font.Face, c color.Color) {
+	drawer := &font.Drawer{
+		Dst:  dst,
+		Src:  &image.Uniform{C: c},
+		Face: face,
+	}
+	metrics := face.Metrics()
+	width := drawer.MeasureString(text)
+	textHeight := metrics.Ascent + metrics.Descent
+	drawer.Dot = fixed.Point26_6{
+		X: fixed.I(rect.Min.X) + (fixed.I(rect.Dx())-width)/2,
+		Y: fixed.I(rect.Min.Y) + (fixed.I(rect.Dy())-textHeight)/2 + metrics.Ascent,
+	}
+	drawer.DrawString(text)
+}
@@ -0,0 +1,42 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// cutLineStroke is the color of the die-cut outline; print workflows conventionally use a
+// magenta spot color named CutContour for it
+const cutLineStroke = "#ff00ff"
+
+// cutLineSVG returns an SVG document with the die-cut outline of the image rendered from bitmap
+// at size pixels with opts
+func cutLineSVG(bitmap [][]bool, quietZone, size int, opts Options) ([]byte, error) {
+	if opts.Banner != nil || opts.Caption != "" || opts.AspectRatio != "" || opts.CropMarks || !opts.Clip.Empty() {
+		return nil, fmt.Errorf("cut line does not support banner, caption, aspect ratio, crop marks or clip")
+	}
+	width, height, radius := size, size, 0
+	switch {
+	case opts.FrameStyle != "":
+		l, err := newFrameLayout(opts.FrameStyle, size)
+		if err != nil {
+			return nil, err
+		}
+		width, height = l.size(size)
+		radius = l.radius
+	case opts.BackgroundPanel:
+		var err error
+		radius, err = panelRadius(opts, newModuleGrid(bitmap, quietZone, size))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d">`,
+		width, height, width, height)
+	fmt.Fprintf(&buf, `<rect id="CutContour" width="%d" height="%d" rx="%d" ry="%d" fill="none" stroke="%s" stroke-width="1"/>`,
+		width, height, radius, radius, cutLineStroke)
+	buf.WriteString(`</svg>`)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,75 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestGenerateWithInfo_CutLine(t *testing.T) {
+	// Version 1 with a 4 module border: 29 modules of 10x10 pixels and a 40px quiet zone
+	base := Options{
+		Data:       "test",
+		Size:       290,
+		Foreground: "black",
+		Background: "white",
+		Error:      "L",
+		Border:     4,
+		CutLine:    true,
+	}
+	tests := []struct {
+		name       string
+		opts       func(*Options)
+		wantRadius int
+	}{
+		{name: "square code", wantRadius: 0},
+		{name: "background panel", opts: func(o *Options) { o.BackgroundPanel = true; o.PanelColor = "blue" }, wantRadius: 20},
+		{name: "panel radius", opts: func(o *Options) { o.BackgroundPanel = true; o.PanelColor = "blue"; o.PanelRadius = 35 }, wantRadius: 35},
+		{name: "rounded badge", opts: func(o *Options) { o.FrameStyle = "rounded-badge" }, wantRadius: 29},
+		{name: "scan me frame", opts: func(o *Options) { o.FrameStyle = "scan-me-bottom" }, wantRadius: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := base
+			if tt.opts != nil {
+				tt.opts(&opts)
+			}
+			data, info, err := GenerateWithInfo(opts)
+			if err != nil {
+				t.Fatalf("GenerateWithInfo() error = %v", err)
+			}
+			img, err := png.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("GenerateWithInfo() returned invalid PNG: %v", err)
+			}
+			svg := string(info.CutLine)
+			size := img.Bounds().Size()
+			if want := fmt.Sprintf(`width="%d" height="%d"`, size.X, size.Y); !strings.Contains(svg, want) {
+				t.Errorf("cut line %s does not match the %dx%d image", svg, size.X, size.Y)
+			}
+			if want := fmt.Sprintf(`rx="%d"`, tt.wantRadius); !strings.Contains(svg, want) {
+				t.Errorf("cut line %s, want corner radius %d", svg, tt.wantRadius)
+			}
+		})
+	}
+
+	plain := base
+	plain.CutLine = false
+	if _, info, err := GenerateWithInfo(plain); err != nil || info.CutLine != nil {
+		t.Errorf("GenerateWithInfo() without CutLine = %q, %v, want no cut line", info.CutLine, err)
+	}
+
+	for name, modify := range map[string]func(*Options){
+		"caption":            func(o *Options) { o.Caption = "Scan me" },
+		"crop marks":         func(o *Options) { o.CropMarks = true },
+		"invalid panel size": func(o *Options) { o.BackgroundPanel = true; o.PanelColor = "blue"; o.PanelRadius = 41 },
+	} {
+		opts := base
+		modify(&opts)
+		if _, _, err := GenerateWithInfo(opts); err == nil {
+			t.Errorf("GenerateWithInfo() with cut line and %s should fail", name)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package qrcode
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Neighbors reports which of the modules adjacent to a dark module are dark as well
+type Neighbors struct {
+	Top, Bottom, Left, Right bool
+}
+
+// ModuleDrawer draws the shape of a single dark module. dst is a coverage mask: the drawer
+// sets the alpha of the pixels inside cell that the shape covers, and the mask is then filled
+// with the foreground color or gradient. Pixels outside cell must not be touched
+type ModuleDrawer interface {
+	Draw(dst draw.Image, cell image.Rectangle, neighbors Neighbors)
+}
+
+// SquareDrawer draws modules as full squares, like the default renderer
+type SquareDrawer struct{}
+
+// Draw fills the whole cell
+func (SquareDrawer) Draw(dst draw.Image, cell image.Rectangle, _ Neighbors) {
+	draw.Draw(dst, cell, image.Opaque, image.Point{}, draw.Src)
+}
+
+// CircleDrawer draws modules as circles inscribed in their cell
+type CircleDrawer struct{}
+
+// Draw fills an anti-aliased circle inscribed in the cell
+func (CircleDrawer) Draw(dst draw.Image, cell image.Rectangle, _ Neighbors) {
+	radius := float64(min(cell.Dx(), cell.Dy())) / 2
+	fillRounded(dst, cell, radius, [4]bool{true, true, true, true})
+}
+
+// RoundedDrawer draws modules as squares with rounded corners. Corners touching a dark
+// neighbor stay square, so connected modules merge into smooth shapes
+type RoundedDrawer struct {
+	// Radius is the corner radius as a fraction of the cell size (0-0.5, default: 0.5)
+	Radius float64
+}
+
+// Draw fills the cell, rounding the corners that have no dark neighbor on either side
+func (d RoundedDrawer) Draw(dst draw.Image, cell image.Rectangle, n Neighbors) {
+	ratio := d.Radius
+	if ratio <= 0 || ratio > 0.5 {
+		ratio = 0.5
+	}
+	radius := float64(min(cell.Dx(), cell.Dy())) * ratio
+	fillRounded(dst, cell, radius, [4]bool{
+		!n.Top && !n.Left,
+		!n.Top && !n.Right,
+		!n.Bottom && !n.Left,
+		!n.Bottom && !n.Right,
+	})
+}
+
+// roundedSamples is the number of samples per pixel axis used to anti-alias rounded corners
+const roundedSamples = 4
+
+// fillRounded fills cell with the given corner radius, rounding only the corners marked in
+// round (top left, top right, bottom left, bottom right)
+func fillRounded(dst draw.Image, cell image.Rectangle, radius float64, round [4]bool) {
+	x0, y0 := float64(cell.Min.X), float64(cell.Min.Y)
+	x1, y1 := float64(cell.Max.X), float64(cell.Max.Y)
+	inside := func(px, py float64) bool {
+		for i, corner := range []struct{ cx, cy float64 }{
+			{x0 + radius, y0 + radius},
+			{x1 - radius, y0 + radius},
+			{x0 + radius, y1 - radius},
+			{x1 - radius, y1 - radius},
+		} {
+			if !round[i] {
+				continue
+			}
+			outsideX := (i%2 == 0 && px < corner.cx) || (i%2 == 1 && px > corner.cx)
+			outsideY := (i < 2 && py < corner.cy) || (i >= 2 && py > corner.cy)
+			if outsideX && outsideY && math.Hypot(px-corner.cx, py-corner.cy) > radius {
+				return false
+			}
+		}
+		return true
+	}
+
+	for y := cell.Min.Y; y < cell.Max.Y; y++ {
+		for x := cell.Min.X; x < cell.Max.X; x++ {
+			covered := 0
+			for sy := 0; sy < roundedSamples; sy++ {
+				for sx := 0; sx < roundedSamples; sx++ {
+					px := float64(x) + (float64(sx)+0.5)/roundedSamples
+					py := float64(y) + (float64(sy)+0.5)/roundedSamples
+					if inside(px, py) {
+						covered++
+					}
+				}
+			}
+			if covered > 0 {
+				dst.Set(x, y, color.Alpha{A: uint8(covered * 255 / (roundedSamples * roundedSamples))})
+			}
+		}
+	}
+}
+
+// drawModuleMask returns the coverage mask of a size x size image of bitmap, with each dark
+// module drawn by drawer into the pixel cell go-qrcode would fill for it
+func drawModuleMask(bitmap [][]bool, size int, drawer ModuleDrawer) *image.Alpha {
+	n := len(bitmap)
+	size = max(size, n)
+
+	edges := moduleEdges(n, size)
+	dark := func(x, y int) bool {
+		return x >= 0 && y >= 0 && x < n && y < n && bitmap[y][x]
+	}
+	mask := image.NewAlpha(image.Rect(0, 0, size, size))
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if !bitmap[y][x] {
+				continue
+			}
+			cell := image.Rect(edges[x], edges[y], edges[x+1], edges[y+1])
+			drawer.Draw(mask, cell, Neighbors{
+				Top:    dark(x, y-1),
+				Bottom: dark(x, y+1),
+				Left:   dark(x-1, y),
+				Right:  dark(x+1, y),
+			})
+		}
+	}
+	return mask
+}
@@ -0,0 +1,145 @@
+package qrcode
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+// countingDrawer records the cells it is asked to draw and fills them completely
+type countingDrawer struct {
+	cells []image.Rectangle
+}
+
+func (d *countingDrawer) Draw(dst draw.Image, cell image.Rectangle, _ Neighbors) {
+	d.cells = append(d.cells, cell)
+	draw.Draw(dst, cell, image.Opaque, image.Point{}, draw.Src)
+}
+
+func TestGenerateImage_ModuleDrawer(t *testing.T) {
+	// Version 1 without border: 21 modules of exactly 10x10 pixels
+	base := Options{
+		Data:       "test",
+		Size:       210,
+		Foreground: "black",
+		Background: "white",
+		Error:      "L",
+	}
+	want, err := GenerateImage(base)
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+
+	t.Run("square matches default", func(t *testing.T) {
+		opts := base
+		opts.ModuleDrawer = SquareDrawer{}
+		got, err := GenerateImage(opts)
+		if err != nil {
+			t.Fatalf("GenerateImage() error = %v", err)
+		}
+		for y := 0; y < want.Bounds().Dy(); y++ {
+			for x := 0; x < want.Bounds().Dx(); x++ {
+				wr, wg, wb, _ := want.At(x, y).RGBA()
+				gr, gg, gb, _ := got.At(x, y).RGBA()
+				if wr != gr || wg != gg || wb != gb {
+					t.Fatalf("pixel (%d,%d) differs from the default renderer", x, y)
+				}
+			}
+		}
+	})
+
+	t.Run("custom drawer gets one cell per dark module", func(t *testing.T) {
+		drawer := &countingDrawer{}
+		opts := base
+		opts.ModuleDrawer = drawer
+		if _, err := GenerateImage(opts); err != nil {
+			t.Fatalf("GenerateImage() error = %v", err)
+		}
+		dark := 0
+		for y := 0; y < 21; y++ {
+			for x := 0; x < 21; x++ {
+				if r, _, _, _ := want.At(x*10, y*10).RGBA(); r == 0 {
+					dark++
+				}
+			}
+		}
+		if len(drawer.cells) != dark {
+			t.Fatalf("Draw called %d times, want %d", len(drawer.cells), dark)
+		}
+		if drawer.cells[0] != image.Rect(0, 0, 10, 10) {
+			t.Errorf("first cell = %v, want %v", drawer.cells[0], image.Rect(0, 0, 10, 10))
+		}
+	})
+
+	tests := []struct {
+		name   string
+		drawer ModuleDrawer
+		x, y   int
+		dark   bool
+	}{
+		// The top left finder's outer ring: module (0,0) has dark neighbors right and below
+		// only, module (6,6) has dark neighbors left and above only
+		{name: "circle cuts the corner", drawer: CircleDrawer{}, x: 0, y: 0, dark: false},
+		{name: "circle keeps the center", drawer: CircleDrawer{}, x: 5, y: 5, dark: true},
+		{name: "rounded cuts an open corner", drawer: RoundedDrawer{}, x: 0, y: 0, dark: false},
+		{name: "rounded keeps a connected corner", drawer: RoundedDrawer{}, x: 69, y: 60, dark: true},
+		{name: "rounded cuts the far open corner", drawer: RoundedDrawer{}, x: 69, y: 69, dark: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := base
+			opts.ModuleDrawer = tt.drawer
+			img, err := GenerateImage(opts)
+			if err != nil {
+				t.Fatalf("GenerateImage() error = %v", err)
+			}
+			got := color.GrayModel.Convert(img.At(tt.x, tt.y)).(color.Gray).Y < 128
+			if got != tt.dark {
+				t.Errorf("pixel (%d,%d) dark = %v, want %v", tt.x, tt.y, got, tt.dark)
+			}
+		})
+	}
+}
+
+func TestGenerateImage_ModuleShapeBevel(t *testing.T) {
+	// Version 2 at 10px per module gives 1px bevel edges; module (0,0) is part of a finder
+	opts := Options{
+		Data:        "https://example.com",
+		Size:        250,
+		Foreground:  "rgb(100,100,100)",
+		Background:  "white",
+		ModuleShape: "bevel",
+	}
+	img, err := GenerateImage(opts)
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		x, y int
+		want uint8
+	}{
+		{"top edge", 5, 0, 146},
+		{"left edge", 0, 5, 146},
+		{"right edge", 9, 5, 70},
+		{"bottom edge", 5, 9, 70},
+		{"center", 5, 5, 100},
+		{"light module", 15, 15, 255},
+	}
+	for _, tt := range tests {
+		if got := color.RGBAModel.Convert(img.At(tt.x, tt.y)).(color.RGBA).R; got != tt.want {
+			t.Errorf("%s: pixel (%d,%d) red = %d, want %d", tt.name, tt.x, tt.y, got, tt.want)
+		}
+	}
+
+	for _, invalid := range []Options{
+		{Data: "test", ModuleShape: "star"},
+		{Data: "test", ModuleShape: "bevel", ModuleDrawer: CircleDrawer{}},
+	} {
+		if _, err := GenerateImage(invalid); err == nil {
+			t.Errorf("GenerateImage() with %+v should fail", invalid)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package qrcode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrorLevel is a typed error correction level for Options.ErrorLevel. The zero value means
+// unset, in which case Options.Error applies
+type ErrorLevel int
+
+// Error correction levels
+const (
+	// LevelLow recovers ~7% of the data
+	LevelLow ErrorLevel = iota + 1
+	// LevelMedium recovers ~15% of the data
+	LevelMedium
+	// LevelQuartile recovers ~25% of the data
+	LevelQuartile
+	// LevelHighest recovers ~30% of the data
+	LevelHighest
+)
+
+// errorLevelNames are the Options.Error letters of the error levels
+var errorLevelNames = map[ErrorLevel]string{
+	LevelLow:      "L",
+	LevelMedium:   "M",
+	LevelQuartile: "Q",
+	LevelHighest:  "H",
+}
+
+// String returns the level's letter as used by Options.Error (L, M, Q or H)
+func (l ErrorLevel) String() string {
+	if name, ok := errorLevelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("ErrorLevel(%d)", int(l))
+}
+
+// ParseErrorLevel parses a level letter (L, M, Q or H, case-insensitive). Unlike Options.Error,
+// which falls back to M, it fails on unknown input
+func ParseErrorLevel(s string) (ErrorLevel, error) {
+	for level, name := range errorLevelNames {
+		if strings.EqualFold(s, name) {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid error level %q: must be L, M, Q or H", s)
+}
@@ -0,0 +1,82 @@
+package qrcode
+
+import "testing"
+
+func TestErrorLevel_String(t *testing.T) {
+	tests := []struct {
+		level ErrorLevel
+		want  string
+	}{
+		{LevelLow, "L"},
+		{LevelMedium, "M"},
+		{LevelQuartile, "Q"},
+		{LevelHighest, "H"},
+		{ErrorLevel(0), "ErrorLevel(0)"},
+		{ErrorLevel(9), "ErrorLevel(9)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.level.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrorLevel(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ErrorLevel
+		wantErr bool
+	}{
+		{input: "L", want: LevelLow},
+		{input: "M", want: LevelMedium},
+		{input: "Q", want: LevelQuartile},
+		{input: "H", want: LevelHighest},
+		{input: "h", want: LevelHighest},
+		{input: "", wantErr: true},
+		{input: "X", wantErr: true},
+		{input: "Low", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseErrorLevel(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseErrorLevel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseErrorLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptions_ErrorLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   ErrorLevel
+		legacy  string
+		want    string
+		wantErr bool
+	}{
+		{name: "typed level", level: LevelQuartile, want: "Q"},
+		{name: "typed overrides string", level: LevelLow, legacy: "H", want: "L"},
+		{name: "string when unset", legacy: "H", want: "H"},
+		{name: "default", want: "M"},
+		{name: "invalid typed level", level: ErrorLevel(7), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, info, err := GenerateWithInfo(Options{Data: "https://example.com", Size: 200, Error: tt.legacy, ErrorLevel: tt.level})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateWithInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if info.RecoveryLevel != tt.want {
+				t.Errorf("Info.RecoveryLevel = %q, want %q", info.RecoveryLevel, tt.want)
+			}
+		})
+	}
+}
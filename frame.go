@@ -0,0 +1,126 @@
+package qrcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+)
+
+// defaultFrameLabel is the frame label text when FrameLabel is unset
+const defaultFrameLabel = "SCAN ME"
+
+// frameLayout holds the pixel dimensions of a frame around a code of a given size
+type frameLayout struct {
+	// band is the width of the frame band, pad the background margin between band and code
+	band, pad int
+	// pointer is the height of the pointer above the label, label the height of the label
+	pointer, label int
+	// radius is the corner radius of the rounded badge
+	radius int
+}
+
+// newFrameLayout scales the frame of style to a code of codeSize pixels
+func newFrameLayout(style string, codeSize int) (frameLayout, error) {
+	l := frameLayout{
+		band:  max(2, codeSize/30),
+		pad:   max(2, codeSize/30),
+		label: max(24, codeSize/6),
+	}
+	switch style {
+	case "scan-me-bottom":
+		l.pointer = max(4, codeSize/20)
+	case "rounded-badge":
+		l.radius = max(4, codeSize/10)
+	default:
+		return frameLayout{}, fmt.Errorf("unsupported frame style %q: must be scan-me-bottom or rounded-badge", style)
+	}
+	return l, nil
+}
+
+// size returns the dimensions of the framed image for a code of codeSize pixels
+func (l frameLayout) size(codeSize int) (int, int) {
+	width := codeSize + 2*(l.band+l.pad)
+	return width, width + l.pointer + l.label
+}
+
+// drawFrame wraps img in the frame of opts.FrameStyle: the code is surrounded by a background
+// margin and a band of the frame color, with the label in a strip below. The code and its quiet
+// zone are never drawn over
+func drawFrame(img image.Image, opts Options, face font.Face, fg, bg color.Color) (*image.RGBA, error) {
+	code := img.Bounds()
+	l, err := newFrameLayout(opts.FrameStyle, code.Dx())
+	if err != nil {
+		return nil, err
+	}
+	frameColor, labelColor := fg, bg
+	if opts.FrameColor != "" {
+		frameColor = parseColor(opts.FrameColor)
+	}
+	if opts.FrameLabelColor != "" {
+		labelColor = parseColor(opts.FrameLabelColor)
+	}
+	label := opts.FrameLabel
+	if label == "" {
+		label = defaultFrameLabel
+	}
+
+	width, height := l.size(code.Dx())
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	inset := l.band + l.pad
+	// The band encloses the code and its margin; the label strip starts below the pointer
+	frame := image.Rect(0, 0, width, code.Dy()+2*inset)
+	strip := image.Rect(0, frame.Max.Y+l.pointer, width, height)
+	frameSrc := image.NewUniform(frameColor)
+	switch opts.FrameStyle {
+	case "scan-me-bottom":
+		draw.Draw(canvas, canvas.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+		draw.Draw(canvas, frame, frameSrc, image.Point{}, draw.Src)
+		draw.Draw(canvas, frame.Inset(l.band), image.NewUniform(bg), image.Point{}, draw.Src)
+		draw.Draw(canvas, strip, frameSrc, image.Point{}, draw.Src)
+		// The pointer is a triangle on top of the label strip pointing up at the code
+		cx := width / 2
+		for dy := 0; dy < l.pointer; dy++ {
+			row := image.Rect(cx-dy-1, frame.Max.Y+dy, cx+dy+1, frame.Max.Y+dy+1)
+			draw.Draw(canvas, row, frameSrc, image.Point{}, draw.Src)
+		}
+	case "rounded-badge":
+		badge := image.NewAlpha(canvas.Bounds())
+		fillRounded(badge, badge.Bounds(), float64(l.radius), [4]bool{true, true, true, true})
+		draw.DrawMask(canvas, canvas.Bounds(), frameSrc, image.Point{}, badge, image.Point{}, draw.Src)
+		window := image.NewAlpha(canvas.Bounds())
+		fillRounded(window, frame.Inset(l.band), float64(l.radius/2), [4]bool{true, true, true, true})
+		draw.DrawMask(canvas, canvas.Bounds(), image.NewUniform(bg), image.Point{}, window, image.Point{}, draw.Over)
+	}
+	draw.Draw(canvas, code.Sub(code.Min).Add(image.Pt(inset, inset)), img, code.Min, draw.Src)
+	drawLabel(canvas, strip, label, face, labelColor)
+	return canvas, nil
+}
+
+// drawPanel rounds the corners of img with radius, filling the area outside the rounded panel
+// with outer
+func drawPanel(img image.Image, radius int, outer color.Color) *image.RGBA {
+	bounds := img.Bounds()
+	canvas := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(outer), image.Point{}, draw.Src)
+	panel := image.NewAlpha(canvas.Bounds())
+	fillRounded(panel, panel.Bounds(), float64(radius), [4]bool{true, true, true, true})
+	draw.DrawMask(canvas, canvas.Bounds(), img, bounds.Min, panel, image.Point{}, draw.Over)
+	return canvas
+}
+
+// panelRadius returns the corner radius of the background panel of opts on grid, checking that
+// it fits in the quiet zone
+func panelRadius(opts Options, grid *moduleGrid) (int, error) {
+	quietZone := grid.pixelBounds(0, 0, 0, 0).Min.X
+	radius := opts.PanelRadius
+	if radius == 0 {
+		radius = quietZone / 2
+	}
+	if radius < 0 || radius > quietZone {
+		return 0, fmt.Errorf("panel radius must be between 0 and the %dpx quiet zone", quietZone)
+	}
+	return radius, nil
+}
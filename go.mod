@@ -8,3 +8,5 @@ require (
 )
 
 require golang.org/x/image v0.35.0
+
+require golang.org/x/text v0.33.0 // indirect
@@ -0,0 +1,53 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+)
+
+// goSourceBytesPerLine is the number of PNG bytes per line of generated Go source
+const goSourceBytesPerLine = 16
+
+// GenerateGoSource generates a PNG QR code and returns a gofmt-formatted Go source file in
+// package pkgName (default: main) declaring it as var varName = []byte{...}, for embedding the
+// code in a binary or library
+func (g *Generator) GenerateGoSource(opts Options, pkgName, varName string) ([]byte, error) {
+	if pkgName == "" {
+		pkgName = "main"
+	}
+	if !token.IsIdentifier(pkgName) || pkgName == "_" {
+		return nil, fmt.Errorf("invalid Go package name %q", pkgName)
+	}
+	if !token.IsIdentifier(varName) {
+		return nil, fmt.Errorf("invalid Go variable name %q", varName)
+	}
+	data, err := g.GeneratePNG(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by go-pkg-qrcode. DO NOT EDIT.\n\npackage %s\n\n", pkgName)
+	fmt.Fprintf(&buf, "// %s is a %d byte PNG image of a QR code\nvar %s = []byte{\n", varName, len(data), varName)
+	for i := 0; i < len(data); i += goSourceBytesPerLine {
+		for _, b := range data[i:min(i+goSourceBytesPerLine, len(data))] {
+			fmt.Fprintf(&buf, "0x%02x, ", b)
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}\n")
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format go source: %w", err)
+	}
+	return src, nil
+}
+
+// GenerateGoSource is a convenience function that creates a generator and generates Go source
+// embedding a PNG QR code
+func GenerateGoSource(opts Options, pkgName, varName string) ([]byte, error) {
+	g := New()
+	return g.GenerateGoSource(opts, pkgName, varName)
+}
@@ -0,0 +1,90 @@
+package qrcode
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"image/png"
+	"strconv"
+	"testing"
+)
+
+func TestGenerateGoSource(t *testing.T) {
+	opts := Options{Data: "https://example.com", Size: 100}
+	src, err := GenerateGoSource(opts, "", "qrCode")
+	if err != nil {
+		t.Fatalf("GenerateGoSource() error = %v", err)
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), "qrcode.go", src, 0)
+	if err != nil {
+		t.Fatalf("GenerateGoSource() returned invalid Go source: %v", err)
+	}
+	if file.Name.Name != "main" {
+		t.Errorf("package = %q, want main", file.Name.Name)
+	}
+
+	// Decode the byte slice literal back into the PNG
+	spec := file.Decls[0].(*ast.GenDecl).Specs[0].(*ast.ValueSpec)
+	if spec.Names[0].Name != "qrCode" {
+		t.Errorf("variable = %q, want qrCode", spec.Names[0].Name)
+	}
+	var data []byte
+	for _, elt := range spec.Values[0].(*ast.CompositeLit).Elts {
+		b, err := strconv.ParseUint(elt.(*ast.BasicLit).Value, 0, 8)
+		if err != nil {
+			t.Fatalf("invalid byte literal: %v", err)
+		}
+		data = append(data, byte(b))
+	}
+	want, err := GeneratePNG(opts)
+	if err != nil {
+		t.Fatalf("GeneratePNG() error = %v", err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Error("embedded bytes differ from GeneratePNG()")
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Errorf("embedded bytes are not a valid PNG: %v", err)
+	}
+
+	for _, name := range []string{"", "1code", "var", "qr-code"} {
+		if _, err := GenerateGoSource(opts, "", name); err == nil {
+			t.Errorf("GenerateGoSource() with variable name %q should fail", name)
+		}
+	}
+}
+
+func TestGenerateGoSource_Package(t *testing.T) {
+	opts := Options{Data: "https://example.com", Size: 100}
+	tests := []struct {
+		pkgName string
+		want    string
+		wantErr bool
+	}{
+		{pkgName: "", want: "main"},
+		{pkgName: "assets", want: "assets"},
+		{pkgName: "qr_codes", want: "qr_codes"},
+		{pkgName: "_", wantErr: true},
+		{pkgName: "func", wantErr: true},
+		{pkgName: "my-assets", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pkgName, func(t *testing.T) {
+			src, err := GenerateGoSource(opts, tt.pkgName, "qrCode")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateGoSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			file, err := parser.ParseFile(token.NewFileSet(), "qrcode.go", src, parser.PackageClauseOnly)
+			if err != nil {
+				t.Fatalf("GenerateGoSource() returned invalid Go source: %v", err)
+			}
+			if file.Name.Name != tt.want {
+				t.Errorf("package = %q, want %q", file.Name.Name, tt.want)
+			}
+		})
+	}
+}
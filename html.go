@@ -0,0 +1,63 @@
+package qrcode
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// GenerateHTMLTable generates a QR code as an HTML <table> with one cell per module, for email
+// clients that do not load images. Modules are Size divided by the module count pixels wide
+// (at least 1); light modules show the table background. Only Size, Foreground, Background,
+// Invert, Border and the encoding options apply. Color alpha is ignored, except that a fully
+// transparent background is drawn white (and a transparent foreground black), since email
+// clients would otherwise show the code on an arbitrary page color
+func (g *Generator) GenerateHTMLTable(opts Options) (string, error) {
+	opts = g.withDefaults(opts)
+	qr, err := g.prepare(&opts)
+	if err != nil {
+		return "", err
+	}
+
+	bitmap := qr.Bitmap()
+	n := len(bitmap)
+	cell := max(1, opts.Size/n)
+	background := htmlColor(qr.BackgroundColor, "#ffffff")
+	foreground := htmlColor(qr.ForegroundColor, "#000000")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<table cellpadding="0" cellspacing="0" border="0" bgcolor="%s" style="border-collapse:collapse;border-spacing:0;background-color:%s">`,
+		background, background)
+	dark := fmt.Sprintf(`<td width="%d" height="%d" bgcolor="%s" style="width:%dpx;height:%dpx;padding:0;background-color:%s"></td>`,
+		cell, cell, foreground, cell, cell, foreground)
+	light := fmt.Sprintf(`<td width="%d" height="%d" style="width:%dpx;height:%dpx;padding:0"></td>`, cell, cell, cell, cell)
+	for _, row := range bitmap {
+		b.WriteString("<tr>")
+		for _, isDark := range row {
+			if isDark {
+				b.WriteString(dark)
+			} else {
+				b.WriteString(light)
+			}
+		}
+		b.WriteString("</tr>")
+	}
+	b.WriteString("</table>")
+	return b.String(), nil
+}
+
+// GenerateHTMLTable is a convenience function that creates a generator and generates a QR code
+// as an HTML table
+func GenerateHTMLTable(opts Options) (string, error) {
+	g := New()
+	return g.GenerateHTMLTable(opts)
+}
+
+// htmlColor returns c as a #rrggbb color, ignoring alpha, or fallback when c is fully transparent
+func htmlColor(c color.Color, fallback string) string {
+	rgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if rgba.A == 0 {
+		return fallback
+	}
+	return fmt.Sprintf("#%02x%02x%02x", rgba.R, rgba.G, rgba.B)
+}
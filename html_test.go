@@ -0,0 +1,61 @@
+package qrcode
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateHTMLTable(t *testing.T) {
+	tests := []struct {
+		name     string
+		border   int
+		wantRows int
+	}{
+		{name: "without quiet zone", border: 0, wantRows: 25},
+		{name: "with quiet zone", border: 4, wantRows: 33},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html, err := GenerateHTMLTable(Options{
+				Data:       "https://example.com",
+				Size:       100,
+				Foreground: "rgb(0,0,255)",
+				Background: "white",
+				Border:     tt.border,
+			})
+			if err != nil {
+				t.Fatalf("GenerateHTMLTable() error = %v", err)
+			}
+			if !strings.HasPrefix(html, "<table") || !strings.HasSuffix(html, "</table>") {
+				t.Fatalf("GenerateHTMLTable() = %.40q..., want a table", html)
+			}
+			if rows := strings.Count(html, "<tr>"); rows != tt.wantRows {
+				t.Errorf("rows = %d, want %d", rows, tt.wantRows)
+			}
+			if cells := strings.Count(html, "<td "); cells != tt.wantRows*tt.wantRows {
+				t.Errorf("cells = %d, want %d", cells, tt.wantRows*tt.wantRows)
+			}
+			if !strings.Contains(html, `bgcolor="#ffffff"`) || !strings.Contains(html, `bgcolor="#0000ff"`) {
+				t.Error("GenerateHTMLTable() should use the background and foreground colors")
+			}
+			// The top left corner is dark only without a quiet zone
+			first := html[strings.Index(html, "<td"):]
+			firstDark := strings.Contains(first[:strings.Index(first, "</td>")], "bgcolor=")
+			if firstDark != (tt.border == 0) {
+				t.Errorf("first module dark = %v, want %v", firstDark, tt.border == 0)
+			}
+		})
+	}
+
+	html, err := GenerateHTMLTable(Options{Data: "https://example.com", Size: 100, Background: "transparent"})
+	if err != nil {
+		t.Fatalf("GenerateHTMLTable() with a transparent background error = %v", err)
+	}
+	if !strings.HasPrefix(html, `<table cellpadding="0" cellspacing="0" border="0" bgcolor="#ffffff"`) {
+		t.Errorf("GenerateHTMLTable() with a transparent background = %.120q..., want a white table", html)
+	}
+
+	if _, err := GenerateHTMLTable(Options{}); err == nil {
+		t.Error("GenerateHTMLTable() with empty data should fail")
+	}
+}
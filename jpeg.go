@@ -0,0 +1,61 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+)
+
+// defaultJPEGQuality is the JPEG quality used when GenerateJPEG is given a quality of 0 or less
+const defaultJPEGQuality = 90
+
+// GenerateJPEG generates a QR code as a JPEG image byte array with the given encoder quality,
+// clamped to 1-100 (default 90 when 0 or less). JPEG has no alpha, so transparent pixels are
+// composited onto white
+func (g *Generator) GenerateJPEG(opts Options, quality int) ([]byte, error) {
+	if quality <= 0 {
+		quality = defaultJPEGQuality
+	}
+	quality = min(quality, 100)
+
+	var out bytes.Buffer
+	_, _, err := g.generate(&out, opts, func(w io.Writer, img image.Image, _ Options) error {
+		return writeJPEG(w, img, quality)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// GenerateJPEG is a convenience function that creates a generator and generates a JPEG QR code
+func GenerateJPEG(opts Options, quality int) ([]byte, error) {
+	g := New()
+	return g.GenerateJPEG(opts, quality)
+}
+
+// encodeJPEG encodes img as a JPEG with the given quality (1-100), compositing it onto white
+func encodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJPEG(&buf, img, quality); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeJPEG encodes img into w as a JPEG with the given quality (1-100), compositing it onto
+// white
+func writeJPEG(w io.Writer, img image.Image, quality int) error {
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	if err := jpeg.Encode(w, flat, &jpeg.Options{Quality: quality}); err != nil {
+		return fmt.Errorf("failed to encode jpeg: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/color"
+	"image/jpeg"
+	"strings"
+	"testing"
+)
+
+func TestGenerateJPEG(t *testing.T) {
+	server := newLogoServer(t, 40, 40, color.RGBA{R: 255, A: 255})
+
+	tests := []struct {
+		name    string
+		opts    Options
+		quality int
+		wantErr bool
+	}{
+		{name: "default quality", opts: Options{Data: "https://example.com", Size: 200}},
+		{name: "low quality", opts: Options{Data: "https://example.com", Size: 200}, quality: 10},
+		{name: "quality above range", opts: Options{Data: "https://example.com", Size: 200}, quality: 500},
+		{
+			name: "gradient and logo",
+			opts: Options{
+				Data:             "https://example.com",
+				Size:             200,
+				GradientStart:    "red",
+				GradientEnd:      "blue",
+				LogoURL:          server.URL,
+				AllowedLogoHosts: testLogoHosts,
+			},
+			quality: 80,
+		},
+		{name: "empty data", opts: Options{Size: 200}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := GenerateJPEG(tt.opts, tt.quality)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateJPEG() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			img, err := jpeg.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("jpeg.Decode() error = %v", err)
+			}
+			if got := img.Bounds().Dx(); got != tt.opts.Size {
+				t.Errorf("GenerateJPEG() width = %d, want %d", got, tt.opts.Size)
+			}
+		})
+	}
+}
+
+func TestGenerateJPEG_QualityClamp(t *testing.T) {
+	opts := Options{Data: "https://example.com", Size: 200}
+	tests := []struct {
+		name    string
+		quality int
+		same    int
+	}{
+		{"zero uses default", 0, defaultJPEGQuality},
+		{"negative uses default", -5, defaultJPEGQuality},
+		{"above 100 clamps", 250, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateJPEG(opts, tt.quality)
+			if err != nil {
+				t.Fatalf("GenerateJPEG() error = %v", err)
+			}
+			want, err := GenerateJPEG(opts, tt.same)
+			if err != nil {
+				t.Fatalf("GenerateJPEG() error = %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("GenerateJPEG(quality %d) differs from quality %d", tt.quality, tt.same)
+			}
+		})
+	}
+}
+
+func TestGenerateJPEG_TransparentOnWhite(t *testing.T) {
+	data, err := GenerateJPEG(Options{Data: "https://example.com", Size: 200, Border: 4, Background: "rgba(0,0,0,0)"}, 100)
+	if err != nil {
+		t.Fatalf("GenerateJPEG() error = %v", err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("jpeg.Decode() error = %v", err)
+	}
+	r, g, b, _ := img.At(1, 1).RGBA()
+	if r>>8 < 240 || g>>8 < 240 || b>>8 < 240 {
+		t.Errorf("transparent quiet zone = (%d,%d,%d), want white", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestGenerator_GenerateJPEGMetrics(t *testing.T) {
+	generator := New()
+	var events []string
+	generator.OnEvent = func(event string, meta map[string]any) {
+		events = append(events, event)
+	}
+
+	data, err := generator.GenerateJPEG(Options{Data: "https://example.com", Size: 200}, 0)
+	if err != nil {
+		t.Fatalf("GenerateJPEG() error = %v", err)
+	}
+	if m := generator.Metrics(); m.CodesGenerated != 1 || m.BytesOut != uint64(len(data)) {
+		t.Errorf("Metrics() = %+v, want 1 code and %d bytes out", m, len(data))
+	}
+	want := []string{EventStart, EventEncoded, EventDone}
+	if strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
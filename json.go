@@ -0,0 +1,46 @@
+package qrcode
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// matrixJSON is the document produced by GenerateJSON
+type matrixJSON struct {
+	Version     int      `json:"version"`
+	ModuleCount int      `json:"moduleCount"`
+	Modules     [][]bool `json:"modules"`
+}
+
+// GenerateJSON encodes opts.Data and returns the module matrix as JSON, e.g.
+//
+//	{"version":1,"moduleCount":21,"modules":[[true,true,...],...]}
+//
+// modules is indexed as [y][x] with true for dark modules and excludes the quiet zone, so
+// clients can render the code themselves. Only the encoding options (Data, Error, version
+// bounds, PayloadWrapper) apply
+func (g *Generator) GenerateJSON(opts Options) ([]byte, error) {
+	opts = g.withDefaults(opts)
+	qr, err := g.prepare(&opts)
+	if err != nil {
+		return nil, err
+	}
+	qr.DisableBorder = true
+
+	data, err := json.Marshal(matrixJSON{
+		Version:     qr.VersionNumber,
+		ModuleCount: symbolSize(qr.VersionNumber),
+		Modules:     qr.Bitmap(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode json: %w", err)
+	}
+	return data, nil
+}
+
+// GenerateJSON is a convenience function that creates a generator and returns a QR code's
+// module matrix as JSON
+func GenerateJSON(opts Options) ([]byte, error) {
+	g := New()
+	return g.GenerateJSON(opts)
+}
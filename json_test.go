@@ -0,0 +1,75 @@
+package qrcode
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGenerateJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        Options
+		wantVersion int
+		wantErr     bool
+	}{
+		{name: "version 1", opts: Options{Data: "test", Error: "L"}, wantVersion: 1},
+		{name: "border is ignored", opts: Options{Data: "test", Error: "L", Border: 8}, wantVersion: 1},
+		{name: "min version", opts: Options{Data: "test", MinVersion: 5}, wantVersion: 5},
+		{name: "missing data", opts: Options{}, wantErr: true},
+		{name: "byte order mark alone is not data", opts: Options{UTF8BOM: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := GenerateJSON(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var doc struct {
+				Version     int      `json:"version"`
+				ModuleCount int      `json:"moduleCount"`
+				Modules     [][]bool `json:"modules"`
+			}
+			if err := json.Unmarshal(data, &doc); err != nil {
+				t.Fatalf("GenerateJSON() returned invalid JSON: %v", err)
+			}
+			if doc.Version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", doc.Version, tt.wantVersion)
+			}
+			if want := symbolSize(tt.wantVersion); doc.ModuleCount != want || len(doc.Modules) != want {
+				t.Fatalf("moduleCount = %d with %d rows, want %d", doc.ModuleCount, len(doc.Modules), want)
+			}
+			// The top left finder starts at the first module: a dark row of 7 then a light separator
+			for x, want := range []bool{true, true, true, true, true, true, true, false} {
+				if doc.Modules[0][x] != want {
+					t.Errorf("module (%d,0) = %v, want %v", x, doc.Modules[0][x], want)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateJSON_UTF8BOM(t *testing.T) {
+	withBOM, err := GenerateJSON(Options{Data: "grüße", UTF8BOM: true})
+	if err != nil {
+		t.Fatalf("GenerateJSON() error = %v", err)
+	}
+	want, err := GenerateJSON(Options{Data: "\xef\xbb\xbfgrüße"})
+	if err != nil {
+		t.Fatalf("GenerateJSON() error = %v", err)
+	}
+	if string(withBOM) != string(want) {
+		t.Error("UTF8BOM should encode the data prefixed with EF BB BF")
+	}
+
+	without, err := GenerateJSON(Options{Data: "grüße"})
+	if err != nil {
+		t.Fatalf("GenerateJSON() error = %v", err)
+	}
+	if string(without) == string(withBOM) {
+		t.Error("data should not get a byte order mark by default")
+	}
+}
@@ -0,0 +1,170 @@
+package qrcode
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// defaultLogoSchemes are the URL schemes allowed for LogoURL when AllowedLogoSchemes is empty
+var defaultLogoSchemes = []string{"http", "https"}
+
+// checkLogoURL parses rawURL and validates it against the logo scheme and host allowlists
+// Hosts not listed in AllowedLogoHosts must not be localhost or a non-public IP address
+func checkLogoURL(rawURL string, opts Options) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid logo url: %w", err)
+	}
+
+	schemes := opts.AllowedLogoSchemes
+	if len(schemes) == 0 {
+		schemes = defaultLogoSchemes
+	}
+	if !slices.ContainsFunc(schemes, func(s string) bool { return strings.EqualFold(s, u.Scheme) }) {
+		return nil, fmt.Errorf("logo url scheme %q is not allowed", u.Scheme)
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		return nil, fmt.Errorf("logo url has no host")
+	}
+	if logoHostListed(host, opts.AllowedLogoHosts) {
+		return u, nil
+	}
+	if len(opts.AllowedLogoHosts) > 0 {
+		return nil, fmt.Errorf("logo host %q is not allowed", host)
+	}
+	lower := strings.ToLower(strings.TrimSuffix(host, "."))
+	if lower == "localhost" || strings.HasSuffix(lower, ".localhost") {
+		return nil, fmt.Errorf("logo host %q is not allowed", host)
+	}
+	if ip := net.ParseIP(host); ip != nil && !publicIP(ip) {
+		return nil, fmt.Errorf("logo host %q is not a public address", host)
+	}
+	return u, nil
+}
+
+// logoHostListed reports whether host matches one of allowed, ignoring case
+func logoHostListed(host string, allowed []string) bool {
+	return slices.ContainsFunc(allowed, func(h string) bool { return strings.EqualFold(h, host) })
+}
+
+// sharedAddressSpace is the carrier-grade NAT range 100.64.0.0/10 (RFC 6598)
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// publicIP reports whether ip is routable on the public internet, i.e. not a loopback,
+// private, carrier-grade NAT, link-local or unspecified address
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip) && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() && !ip.IsUnspecified()
+}
+
+// defaultLogoTimeout limits a logo fetch when Options.LogoTimeout is not set
+const defaultLogoTimeout = 10 * time.Second
+
+// fetchLogo validates opts.LogoURL and fetches it, failing on a non-200 response. Unlisted
+// hosts are checked again at connect time, so names resolving to non-public addresses are
+// refused, and redirects are validated like the original URL. With opts.HTTPClient its
+// transport is used as is and only the URLs are validated
+func fetchLogo(opts Options) (*http.Response, error) {
+	u, err := checkLogoURL(opts.LogoURL, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	checkRedirect := func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		_, err := checkLogoURL(req.URL.String(), opts)
+		return err
+	}
+	var client *http.Client
+	if opts.HTTPClient != nil {
+		custom := *opts.HTTPClient
+		if next := custom.CheckRedirect; next != nil {
+			custom.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+				if err := checkRedirect(req, via); err != nil {
+					return err
+				}
+				return next(req, via)
+			}
+		} else {
+			custom.CheckRedirect = checkRedirect
+		}
+		client = &custom
+	} else {
+		client = &http.Client{Transport: logoTransport, CheckRedirect: checkRedirect}
+	}
+	switch {
+	case opts.LogoTimeout > 0:
+		client.Timeout = opts.LogoTimeout
+	case client.Timeout == 0:
+		client.Timeout = defaultLogoTimeout
+	}
+
+	ctx := context.WithValue(context.Background(), logoHostsKey{}, opts.AllowedLogoHosts)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("logo host returned status %d (%s)", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
+
+// logoHostsKey is the request context key for the AllowedLogoHosts that logoTransport dials
+type logoHostsKey struct{}
+
+// logoTransport is shared by all logo fetches so idle connections are reused and expire. It
+// refuses to connect to non-public addresses unless the host is among the AllowedLogoHosts in
+// the request context. Behind a proxy from the environment only the proxy is dialed, so a
+// private proxy must be listed and the URL checks are all that apply to the logo host
+var logoTransport = &http.Transport{
+	Proxy:                 http.ProxyFromEnvironment,
+	DialContext:           dialLogoHost,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          100,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ExpectContinueTimeout: time.Second,
+}
+
+// publicDialer only connects to public addresses
+var publicDialer = &net.Dialer{
+	Control: func(_, address string, _ syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+			return fmt.Errorf("logo host resolves to non-public address %s", host)
+		}
+		return nil
+	},
+}
+
+// dialLogoHost dials address, requiring a public address unless its host is listed in the
+// AllowedLogoHosts of ctx
+func dialLogoHost(ctx context.Context, network, address string) (net.Conn, error) {
+	allowed, _ := ctx.Value(logoHostsKey{}).([]string)
+	host, _, err := net.SplitHostPort(address)
+	if err == nil && logoHostListed(host, allowed) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, address)
+	}
+	return publicDialer.DialContext(ctx, network, address)
+}
@@ -0,0 +1,176 @@
+package qrcode
+
+import (
+	"image/color"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGeneratePNG_LogoURLAllowlist(t *testing.T) {
+	server := newLogoServer(t, 50, 50, color.RGBA{R: 255, A: 255})
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, strings.Replace(server.URL, "127.0.0.1", "localhost", 1), http.StatusFound)
+	}))
+	t.Cleanup(redirect.Close)
+
+	tests := []struct {
+		name    string
+		url     string
+		schemes []string
+		hosts   []string
+		wantErr bool
+	}{
+		{name: "listed loopback host", url: server.URL, hosts: testLogoHosts},
+		{name: "listed host ignores case", url: server.URL, hosts: []string{"127.0.0.1", "EXAMPLE.com"}},
+		{name: "file scheme", url: "file:///etc/passwd", wantErr: true},
+		{name: "localhost", url: "http://localhost/logo.png", wantErr: true},
+		{name: "localhost subdomain", url: "http://app.localhost/logo.png", wantErr: true},
+		{name: "unlisted loopback address", url: server.URL, wantErr: true},
+		{name: "private address", url: "http://10.0.0.1/logo.png", wantErr: true},
+		{name: "link-local metadata address", url: "http://169.254.169.254/latest", wantErr: true},
+		{name: "private IPv6 address", url: "http://[fd00::1]/logo.png", wantErr: true},
+		{name: "carrier-grade NAT address", url: "http://100.64.0.1/logo.png", wantErr: true},
+		{name: "host not in allowlist", url: server.URL, hosts: []string{"example.com"}, wantErr: true},
+		{name: "scheme not in allowlist", url: server.URL, schemes: []string{"https"}, hosts: testLogoHosts, wantErr: true},
+		{name: "redirect to unlisted host", url: redirect.URL, hosts: testLogoHosts, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := GeneratePNG(Options{
+				Data:               "https://example.com",
+				LogoURL:            tt.url,
+				AllowedLogoSchemes: tt.schemes,
+				AllowedLogoHosts:   tt.hosts,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GeneratePNG() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGeneratePNG_LogoFetchErrors(t *testing.T) {
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(notFound.Close)
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	t.Cleanup(slow.Close)
+
+	tests := []struct {
+		name    string
+		url     string
+		timeout time.Duration
+		wantErr string
+	}{
+		{name: "not found", url: notFound.URL, wantErr: "logo host returned status 404 (Not Found)"},
+		{name: "timeout", url: slow.URL, timeout: 50 * time.Millisecond, wantErr: "Client.Timeout exceeded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			_, err := GeneratePNG(Options{
+				Data:             "https://example.com",
+				LogoURL:          tt.url,
+				AllowedLogoHosts: testLogoHosts,
+				LogoTimeout:      tt.timeout,
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("GeneratePNG() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("GeneratePNG() took %v", elapsed)
+			}
+		})
+	}
+}
+
+func TestGeneratePNG_LogoHTTPClient(t *testing.T) {
+	server := newLogoServer(t, 50, 50, color.RGBA{R: 255, A: 255})
+	var requests []string
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req.URL.String())
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+
+	if _, err := GeneratePNG(Options{
+		Data:             "https://example.com",
+		LogoURL:          server.URL + "/logo.png",
+		AllowedLogoHosts: testLogoHosts,
+		HTTPClient:       client,
+	}); err != nil {
+		t.Fatalf("GeneratePNG() error = %v", err)
+	}
+	if len(requests) != 1 || requests[0] != server.URL+"/logo.png" {
+		t.Errorf("client requests = %v, want one request for the logo", requests)
+	}
+
+	if _, err := GeneratePNG(Options{Data: "https://example.com", LogoURL: server.URL, HTTPClient: client}); err == nil {
+		t.Error("GeneratePNG() with a custom client should still enforce the host allowlist")
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"100.63.255.255", true},
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"100.128.0.1", true},
+		{"10.1.2.3", false},
+		{"127.0.0.1", false},
+		{"169.254.169.254", false},
+		{"2606:2800:220:1::", true},
+		{"fd00::1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := publicIP(net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("publicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeneratePNG_LogoConnectionReuse(t *testing.T) {
+	logo := newLogoServer(t, 50, 50, color.RGBA{R: 255, A: 255})
+	var conns atomic.Int32
+	server := httptest.NewUnstartedServer(logo.Config.Handler)
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+
+	for i := 0; i < 3; i++ {
+		if _, err := GeneratePNG(Options{Data: "https://example.com", LogoURL: server.URL, AllowedLogoHosts: testLogoHosts}); err != nil {
+			t.Fatalf("GeneratePNG() error = %v", err)
+		}
+	}
+	if got := conns.Load(); got != 1 {
+		t.Errorf("logo fetches opened %d connections, want 1", got)
+	}
+	if logoTransport.Proxy == nil {
+		t.Error("logo transport should honor proxy settings from the environment")
+	}
+}
@@ -0,0 +1,444 @@
+package qrcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// moduleKind classifies a module of a QR symbol by the structure it belongs to
+type moduleKind uint8
+
+const (
+	moduleData moduleKind = iota
+	moduleFinder
+	moduleSeparator
+	moduleTiming
+	moduleAlignment
+	moduleFormat
+	moduleVersion
+)
+
+// quietZoneModules is the width of the quiet zone go-qrcode draws around the symbol
+const quietZoneModules = 4
+
+// formatInfoMask is XORed with the BCH-coded format information (ISO/IEC 18004 §7.9)
+const formatInfoMask = 0x5412
+
+// symbolSize returns the number of modules per side for a QR version (without quiet zone)
+func symbolSize(version int) int {
+	return 17 + 4*version
+}
+
+// alignmentCenters returns the row/column coordinates of alignment pattern centers for a version
+func alignmentCenters(version int) []int {
+	if version < 2 {
+		return nil
+	}
+	count := version/7 + 2
+	size := symbolSize(version)
+	step := 26
+	if version != 32 {
+		step = (version*4 + count*2 + 1) / (count*2 - 2) * 2
+	}
+	centers := make([]int, count)
+	centers[0] = 6
+	for i, pos := count-1, size-7; i > 0; i, pos = i-1, pos-step {
+		centers[i] = pos
+	}
+	return centers
+}
+
+// classifyModules returns the kind of every module of a symbol of the given version,
+// indexed as [y][x] without quiet zone
+func classifyModules(version int) [][]moduleKind {
+	size := symbolSize(version)
+	kinds := make([][]moduleKind, size)
+	for y := range kinds {
+		kinds[y] = make([]moduleKind, size)
+	}
+	fill := func(x0, y0, w, h int, kind moduleKind) {
+		for y := y0; y < y0+h; y++ {
+			for x := x0; x < x0+w; x++ {
+				if x >= 0 && y >= 0 && x < size && y < size {
+					kinds[y][x] = kind
+				}
+			}
+		}
+	}
+
+	// Separators first, so the finder patterns drawn on top leave only the white rim
+	fill(0, 0, 8, 8, moduleSeparator)
+	fill(size-8, 0, 8, 8, moduleSeparator)
+	fill(0, size-8, 8, 8, moduleSeparator)
+	fill(0, 0, 7, 7, moduleFinder)
+	fill(size-7, 0, 7, 7, moduleFinder)
+	fill(0, size-7, 7, 7, moduleFinder)
+
+	for i := 8; i < size-8; i++ {
+		kinds[6][i] = moduleTiming
+		kinds[i][6] = moduleTiming
+	}
+
+	centers := alignmentCenters(version)
+	for _, cy := range centers {
+		for _, cx := range centers {
+			if kinds[cy][cx] == moduleFinder || kinds[cy][cx] == moduleSeparator {
+				continue
+			}
+			fill(cx-2, cy-2, 5, 5, moduleAlignment)
+		}
+	}
+
+	fill(8, 0, 1, 9, moduleFormat)
+	fill(0, 8, 9, 1, moduleFormat)
+	fill(size-8, 8, 8, 1, moduleFormat)
+	fill(8, size-8, 1, 8, moduleFormat)
+	kinds[6][8] = moduleTiming
+	kinds[8][6] = moduleTiming
+
+	if version >= 7 {
+		fill(size-11, 0, 3, 6, moduleVersion)
+		fill(0, size-11, 6, 3, moduleVersion)
+	}
+	return kinds
+}
+
+// maskBit reports whether the given mask pattern inverts the module at (x, y)
+func maskBit(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (y/2+x/3)%2 == 0
+	case 5:
+		return (x*y)%2+(x*y)%3 == 0
+	case 6:
+		return ((x*y)%2+(x*y)%3)%2 == 0
+	default:
+		return ((x+y)%2+(x*y)%3)%2 == 0
+	}
+}
+
+// readMask extracts the data mask pattern from the format information next to the top left
+// finder, which holds the 15 format bits starting with the least significant one
+func readMask(bitmap [][]bool) int {
+	var bits uint16
+	n := 0
+	read := func(x, y int) {
+		if bitmap[y][x] {
+			bits |= 1 << n
+		}
+		n++
+	}
+	for i := 0; i <= 5; i++ {
+		read(8, i)
+	}
+	read(8, 7)
+	read(8, 8)
+	read(7, 8)
+	for i := 9; i <= 14; i++ {
+		read(14-i, 8)
+	}
+	return int((bits^formatInfoMask)>>10) & 0x7
+}
+
+// placementOrder returns the coordinates of the data modules of a symbol in the order
+// codeword bits are placed (two-column zig-zag from the bottom right corner)
+func placementOrder(kinds [][]moduleKind) []image.Point {
+	size := len(kinds)
+	order := make([]image.Point, 0, size*size)
+	upward := true
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for i := 0; i < size; i++ {
+			y := i
+			if upward {
+				y = size - 1 - i
+			}
+			for _, x := range []int{right, right - 1} {
+				if kinds[y][x] == moduleData {
+					order = append(order, image.Point{X: x, Y: y})
+				}
+			}
+		}
+		upward = !upward
+	}
+	return order
+}
+
+// moduleGrid maps the pixels of a rendered image onto the modules of its symbol the same
+// way go-qrcode does when drawing, so individual modules can be restyled after rendering
+type moduleGrid struct {
+	bitmap    [][]bool
+	kinds     [][]moduleKind
+	quietZone int
+	size      int
+
+	// mask, when set, is the coverage of the shapes drawn by a ModuleDrawer over background
+	mask       *image.Alpha
+	background color.Color
+}
+
+// newModuleGrid maps a size x size image onto bitmap, which includes a quiet zone of quietZone
+// modules. kinds is nil when the symbol is not a valid QR version size
+func newModuleGrid(bitmap [][]bool, quietZone, size int) *moduleGrid {
+	grid := &moduleGrid{
+		bitmap:    bitmap,
+		quietZone: quietZone,
+		size:      size,
+	}
+	if n := len(bitmap) - 2*quietZone; n >= symbolSize(1) && n <= symbolSize(40) && (n-17)%4 == 0 {
+		grid.kinds = classifyModules((n - 17) / 4)
+	}
+	return grid
+}
+
+// moduleEdges returns the first pixel of each of n modules drawn across size >= n pixels the way
+// go-qrcode maps pixels to modules, followed by size
+func moduleEdges(n, size int) []int {
+	edges := make([]int, n+1)
+	edges[n] = size
+	modulesPerPixel := float64(n) / float64(size)
+	for p := size - 1; p >= 0; p-- {
+		edges[int(float64(p)*modulesPerPixel)] = p
+	}
+	return edges
+}
+
+// module returns the symbol coordinates of the module covering pixel (x, y) and whether
+// the pixel lies inside the symbol rather than in the quiet zone
+func (m *moduleGrid) module(x, y int) (int, int, bool) {
+	modulesPerPixel := float64(len(m.bitmap)) / float64(m.size)
+	mx := int(float64(x)*modulesPerPixel) - m.quietZone
+	my := int(float64(y)*modulesPerPixel) - m.quietZone
+	n := len(m.bitmap) - 2*m.quietZone
+	inside := mx >= 0 && my >= 0 && mx < n && my < n
+	return mx, my, inside
+}
+
+// dark reports whether the module at symbol coordinates (mx, my) is dark
+func (m *moduleGrid) dark(mx, my int) bool {
+	return m.bitmap[my+m.quietZone][mx+m.quietZone]
+}
+
+// recolor paints the pixels of dark modules of the given kind with c, following the module
+// shapes when they were drawn by a ModuleDrawer
+func (m *moduleGrid) recolor(img *image.RGBA, kind moduleKind, c color.Color) {
+	fill := image.NewUniform(c)
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			mx, my, inside := m.module(x, y)
+			if !inside || m.kinds[my][mx] != kind || !m.dark(mx, my) {
+				continue
+			}
+			if m.mask == nil {
+				img.Set(x, y, c)
+				continue
+			}
+			if m.mask.AlphaAt(x, y).A == 0 {
+				continue
+			}
+			img.Set(x, y, m.background)
+			draw.DrawMask(img, image.Rect(x, y, x+1, y+1), fill, image.Point{}, m.mask, image.Pt(x, y), draw.Over)
+		}
+	}
+}
+
+// recolorLight paints the pixels of light modules inside the symbol with c, leaving the quiet zone
+func (m *moduleGrid) recolorLight(img *image.RGBA, c color.Color) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			mx, my, inside := m.module(x, y)
+			if inside && !m.dark(mx, my) {
+				img.Set(x, y, c)
+			}
+		}
+	}
+}
+
+// lightMask returns a mask covering the pixels of light modules, including the quiet zone, and
+// the parts of dark module cells a ModuleDrawer left uncovered
+func (m *moduleGrid) lightMask() *image.Alpha {
+	mask := image.NewAlpha(image.Rect(0, 0, m.size, m.size))
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			mx, my, _ := m.module(x, y)
+			switch {
+			case !m.dark(mx, my):
+				mask.SetAlpha(x, y, color.Alpha{A: 255})
+			case m.mask != nil:
+				mask.SetAlpha(x, y, color.Alpha{A: 255 - m.mask.AlphaAt(x, y).A})
+			}
+		}
+	}
+	return mask
+}
+
+// bevelShade is how far bevel edges are mixed toward white (top/left) and black (bottom/right)
+const bevelShade = 0.3
+
+// bevel gives every dark module a light top and left edge and a dark bottom and right edge,
+// each a sixth of the module wide, shading the module's current colors
+func (m *moduleGrid) bevel(img *image.RGBA) {
+	n := len(m.bitmap)
+	if m.size < n {
+		return
+	}
+	edges := moduleEdges(n, m.size)
+	for my, row := range m.bitmap {
+		for mx, dark := range row {
+			if !dark {
+				continue
+			}
+			cell := image.Rect(edges[mx], edges[my], edges[mx+1], edges[my+1])
+			band := max(1, min(cell.Dx(), cell.Dy())/6)
+			for y := cell.Min.Y; y < cell.Max.Y; y++ {
+				for x := cell.Min.X; x < cell.Max.X; x++ {
+					topLeft := min(x-cell.Min.X, y-cell.Min.Y)
+					bottomRight := min(cell.Max.X-1-x, cell.Max.Y-1-y)
+					if min(topLeft, bottomRight) >= band {
+						continue
+					}
+					c := img.RGBAAt(x, y)
+					var target uint8
+					if topLeft <= bottomRight {
+						target = 255
+					}
+					shade := func(v uint8) uint8 {
+						return uint8(float64(v) + (float64(target)*float64(c.A)/255-float64(v))*bevelShade)
+					}
+					img.SetRGBA(x, y, color.RGBA{R: shade(c.R), G: shade(c.G), B: shade(c.B), A: c.A})
+				}
+			}
+		}
+	}
+}
+
+// sequentialGradient renders a gradient over the image in which every data module takes mix
+// at its position (0-1) in the placement order; other modules and the quiet zone take mix(0)
+func (m *moduleGrid) sequentialGradient(mix func(ratio float64) color.RGBA) *image.RGBA {
+	order := placementOrder(m.kinds)
+	ratios := make([][]float64, len(m.kinds))
+	for y := range ratios {
+		ratios[y] = make([]float64, len(m.kinds))
+	}
+	for i, p := range order {
+		ratios[p.Y][p.X] = float64(i) / float64(max(1, len(order)-1))
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, m.size, m.size))
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			var ratio float64
+			if mx, my, inside := m.module(x, y); inside {
+				ratio = ratios[my][mx]
+			}
+			img.SetRGBA(x, y, mix(ratio))
+		}
+	}
+	return img
+}
+
+// overlapsFinders reports whether the pixel rectangle r touches a finder pattern or its separator
+func (m *moduleGrid) overlapsFinders(r image.Rectangle) bool {
+	last := len(m.kinds) - 1
+	for _, finder := range [][4]int{{0, 0, 7, 7}, {last - 7, 0, last, 7}, {0, last - 7, 7, last}} {
+		if m.pixelBounds(finder[0], finder[1], finder[2], finder[3]).Overlaps(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// snapToModules returns the pixel rectangle of the whole modules touched by the pixel rectangle r
+func (m *moduleGrid) snapToModules(r image.Rectangle) image.Rectangle {
+	mx0, my0, _ := m.module(r.Min.X, r.Min.Y)
+	mx1, my1, _ := m.module(r.Max.X-1, r.Max.Y-1)
+	return m.pixelBounds(mx0, my0, mx1, my1)
+}
+
+// pixelBounds returns the pixel rectangle covered by the modules from (mx0, my0) to (mx1, my1) inclusive
+func (m *moduleGrid) pixelBounds(mx0, my0, mx1, my1 int) image.Rectangle {
+	r := image.Rectangle{Min: image.Pt(m.size, m.size)}
+	for p := 0; p < m.size; p++ {
+		mp, _, _ := m.module(p, 0)
+		if mp >= mx0 && mp <= mx1 {
+			r.Min.X = min(r.Min.X, p)
+			r.Max.X = max(r.Max.X, p+1)
+		}
+		if mp >= my0 && mp <= my1 {
+			r.Min.Y = min(r.Min.Y, p)
+			r.Max.Y = max(r.Max.Y, p+1)
+		}
+	}
+	return r
+}
+
+// finderCenters returns the symbol coordinates of the center module of each finder pattern
+func (m *moduleGrid) finderCenters() []image.Point {
+	last := len(m.kinds) - 4
+	return []image.Point{{X: 3, Y: 3}, {X: last, Y: 3}, {X: 3, Y: last}}
+}
+
+// roundEyeBalls replaces the square 3x3 center of each finder pattern with a circle,
+// painting the cut-off corners with the light module color and keeping the existing color
+// inside the circle
+func (m *moduleGrid) roundEyeBalls(img *image.RGBA, light color.Color) {
+	for _, c := range m.finderCenters() {
+		r := m.pixelBounds(c.X-1, c.Y-1, c.X+1, c.Y+1)
+		cx := float64(r.Min.X+r.Max.X) / 2
+		cy := float64(r.Min.Y+r.Max.Y) / 2
+		radius := float64(min(r.Dx(), r.Dy())) / 2
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				dx, dy := float64(x)+0.5-cx, float64(y)+0.5-cy
+				if dx*dx+dy*dy > radius*radius {
+					img.Set(x, y, light)
+				}
+			}
+		}
+	}
+}
+
+// RawCodewords returns the final codeword sequence (data and error correction, interleaved
+// as placed in the symbol) that encodes data at the given error correction level.
+// It is intended for diagnostics and analysis tooling
+func RawCodewords(data, errorLevel string) ([]byte, error) {
+	if data == "" {
+		return nil, fmt.Errorf("data is required")
+	}
+
+	qr, err := qrcode.New(data, getErrorCorrection(errorLevel))
+	if err != nil {
+		return nil, fmt.Errorf("failed to init qrcode: %w", err)
+	}
+	qr.DisableBorder = true
+	bitmap := qr.Bitmap()
+
+	mask := readMask(bitmap)
+	order := placementOrder(classifyModules(qr.VersionNumber))
+	codewords := make([]byte, len(order)/8)
+	for i := range codewords {
+		var b byte
+		for _, p := range order[i*8 : i*8+8] {
+			b <<= 1
+			if bitmap[p.Y][p.X] != maskBit(mask, p.X, p.Y) {
+				b |= 1
+			}
+		}
+		codewords[i] = b
+	}
+	return codewords, nil
+}
@@ -0,0 +1,135 @@
+package qrcode
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestClassifyModules_DataModuleCount(t *testing.T) {
+	for version := 1; version <= 40; version++ {
+		// Number of raw data modules per ISO/IEC 18004 (codewords plus remainder bits)
+		want := (16*version+128)*version + 64
+		if version >= 2 {
+			count := version/7 + 2
+			want -= (25*count-10)*count - 55
+			if version >= 7 {
+				want -= 36
+			}
+		}
+
+		got := len(placementOrder(classifyModules(version)))
+		if got != want {
+			t.Errorf("version %d: got %d data modules, want %d", version, got, want)
+		}
+	}
+}
+
+func TestModuleGrid_SequentialGradient(t *testing.T) {
+	// Version 1 at 10px per module without a quiet zone
+	bitmap := make([][]bool, 21)
+	for y := range bitmap {
+		bitmap[y] = make([]bool, 21)
+	}
+	grid := newModuleGrid(bitmap, 0, 210)
+	mix, _ := gradientMix(color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}, "srgb")
+	gradient := grid.sequentialGradient(mix)
+
+	order := placementOrder(grid.kinds)
+	last := order[len(order)-1]
+	middle := order[len(order)/2]
+	tests := []struct {
+		name string
+		at   image.Point
+		want color.RGBA
+	}{
+		{"first data module", image.Pt(205, 205), color.RGBA{R: 255, A: 255}},
+		{"last data module", image.Pt(last.X*10+5, last.Y*10+5), color.RGBA{B: 255, A: 255}},
+		{"middle data module", image.Pt(middle.X*10+5, middle.Y*10+5), mix(float64(len(order)/2) / float64(len(order)-1))},
+		{"finder pattern", image.Pt(5, 5), color.RGBA{R: 255, A: 255}},
+	}
+	for _, tt := range tests {
+		if got := gradient.RGBAAt(tt.at.X, tt.at.Y); got != tt.want {
+			t.Errorf("%s: pixel %v = %v, want %v", tt.name, tt.at, got, tt.want)
+		}
+	}
+
+	if _, err := RenderMatrix([][]bool{{true, false}, {false, true}}, RenderOptions{
+		Options: Options{Size: 20, GradientStart: "red", GradientEnd: "blue", GradientType: "sequential"},
+	}); err == nil {
+		t.Error("RenderMatrix() with a sequential gradient on a non-QR matrix should fail")
+	}
+}
+
+func TestRawCodewords(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		level     string
+		wantLen   int
+		wantFirst byte
+	}{
+		// Version 1-M: 26 codewords, byte mode indicator 0100 followed by an 8-bit count of 11
+		{"version 1", "hello world", "M", 26, 0x40},
+		// Version 8-M: 242 codewords, byte mode with a count of 150 (0x96)
+		{"version 8", strings.Repeat("a", 150), "M", 242, 0x49},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codewords, err := RawCodewords(tt.data, tt.level)
+			if err != nil {
+				t.Fatalf("RawCodewords() error = %v", err)
+			}
+			if len(codewords) != tt.wantLen {
+				t.Errorf("RawCodewords() returned %d codewords, want %d", len(codewords), tt.wantLen)
+			}
+			if codewords[0] != tt.wantFirst {
+				t.Errorf("RawCodewords() first codeword = %#x, want %#x", codewords[0], tt.wantFirst)
+			}
+		})
+	}
+
+	if _, err := RawCodewords("", "M"); err == nil {
+		t.Error("RawCodewords() with empty data should fail")
+	}
+}
+
+func TestRawCodewords_AllMasks(t *testing.T) {
+	// Byte mode payloads up to version 9 start with 0100 and an 8-bit count; varying the
+	// length and level exercises every mask pattern the encoder may choose
+	for _, level := range []string{"L", "M", "Q", "H"} {
+		for n := 1; n <= 80; n++ {
+			codewords, err := RawCodewords(strings.Repeat("a", n), level)
+			if err != nil {
+				t.Fatalf("RawCodewords() error = %v", err)
+			}
+			if want := byte(0x40 | n>>4); codewords[0] != want {
+				t.Errorf("level %s, length %d: first codeword = %#x, want %#x", level, n, codewords[0], want)
+			}
+		}
+	}
+}
+
+func TestModuleGrid_LightMask(t *testing.T) {
+	bitmap := [][]bool{
+		{false, true},
+		{true, false},
+	}
+	mask := newModuleGrid(bitmap, 0, 4).lightMask()
+	tests := []struct {
+		x, y int
+		want uint8
+	}{
+		{0, 0, 255},
+		{3, 0, 0},
+		{0, 3, 0},
+		{3, 3, 255},
+	}
+	for _, tt := range tests {
+		if got := mask.AlphaAt(tt.x, tt.y).A; got != tt.want {
+			t.Errorf("light mask at (%d,%d) = %d, want %d", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"time"
+)
+
+// GenerateOverlay generates primary with a small secondary code composited into its bottom
+// right corner, the only corner without a finder pattern, so a single image carries both
+// payloads. The secondary code always keeps a quiet zone, which separates it from the primary
+// modules, and defaults to a quarter of the primary size. It must not reach the primary finder
+// patterns. The covered primary modules are recovered by error correction, so primary defaults
+// to error level H. Layout options that move the primary code (FrameStyle, Caption,
+// AspectRatio, CropMarks and Clip) are not supported
+func (g *Generator) GenerateOverlay(primary, secondary Options) ([]byte, error) {
+	primary = g.withDefaults(primary)
+	if primary.FrameStyle != "" || primary.Caption != "" || primary.AspectRatio != "" || primary.CropMarks || !primary.Clip.Empty() {
+		return nil, fmt.Errorf("overlay does not support frame, caption, aspect ratio, crop marks or clip on the primary code")
+	}
+	// Resolve the border up front so the quiet zone below matches the one rendered
+	if err := resolvePhysicalSize(&primary); err != nil {
+		return nil, err
+	}
+	if primary.Error == "" && primary.ErrorLevel == 0 {
+		primary.Error = "H"
+	}
+	start := time.Now()
+	primaryImg, info, err := g.render(primary, start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render primary code: %w", err)
+	}
+
+	secondary = g.withDefaults(secondary)
+	if secondary.Size <= 0 {
+		secondary.Size = info.Size / 4
+	}
+	if secondary.Border == 0 {
+		secondary.Border = quietZoneModules
+	}
+	secondaryImg, _, err := g.render(secondary, start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render secondary code: %w", err)
+	}
+
+	// Align the secondary code with the inner corner of the primary quiet zone
+	quietZone := 0
+	if primary.Border != 0 {
+		quietZone = quietZoneModules
+	}
+	modulePx := float64(info.Size) / float64(info.Modules+2*quietZone)
+	inner := info.Size - int(float64(quietZone)*modulePx)
+	size := secondaryImg.Bounds().Size()
+	pos := image.Rect(inner-size.X, inner-size.Y, inner, inner)
+	// Finder patterns and their separators span 8 modules from the symbol edge
+	finderEdge := int(float64(quietZone+8) * modulePx)
+	if pos.Min.X < finderEdge || pos.Min.Y < finderEdge {
+		return nil, fmt.Errorf("secondary code of %dx%d pixels overlaps the primary finder patterns", size.X, size.Y)
+	}
+
+	rgba := toRGBA(primaryImg)
+	draw.Draw(rgba, pos, secondaryImg, secondaryImg.Bounds().Min, draw.Src)
+	out, err := convertColorModel(rgba, primary)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := writePNG(&buf, out, primary); err != nil {
+		return nil, err
+	}
+	g.generated.Add(1)
+	g.bytesOut.Add(uint64(buf.Len()))
+	return buf.Bytes(), nil
+}
+
+// GenerateOverlay is a convenience function that creates a generator and generates a QR code
+// with a secondary code in its bottom right corner
+func GenerateOverlay(primary, secondary Options) ([]byte, error) {
+	g := New()
+	return g.GenerateOverlay(primary, secondary)
+}
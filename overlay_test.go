@@ -0,0 +1,83 @@
+package qrcode
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func TestGenerateOverlay(t *testing.T) {
+	// Version 3 at error level H: 37 modules with the quiet zone, so the inner corner of the
+	// quiet zone lies at 330 - 35 = 295 pixels
+	primary := Options{Data: "https://example.com", Size: 330, Border: 4, Foreground: "black", Background: "white"}
+	secondary := Options{Data: "test", Foreground: "black", Background: "white"}
+
+	data, err := GenerateOverlay(primary, secondary)
+	if err != nil {
+		t.Fatalf("GenerateOverlay() error = %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("GenerateOverlay() returned invalid PNG: %v", err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 330, 330) {
+		t.Fatalf("GenerateOverlay() bounds = %v, want 330x330", img.Bounds())
+	}
+
+	// The secondary code defaults to a quarter of the primary size and keeps its quiet zone
+	want, err := GenerateImage(Options{Data: "test", Size: 82, Border: 4, Foreground: "black", Background: "white"})
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+	origin := image.Pt(295-82, 295-82)
+	for y := 0; y < 82; y++ {
+		for x := 0; x < 82; x++ {
+			wr, _, _, _ := want.At(x, y).RGBA()
+			gr, _, _, _ := img.At(origin.X+x, origin.Y+y).RGBA()
+			if wr != gr {
+				t.Fatalf("overlay pixel (%d,%d) differs from the secondary code", x, y)
+			}
+		}
+	}
+	if r, _, _, _ := img.At(300, 300).RGBA(); r != 0xffff {
+		t.Error("primary quiet zone should stay clear")
+	}
+
+	tests := []struct {
+		name      string
+		primary   Options
+		secondary Options
+	}{
+		{name: "secondary too large", primary: primary, secondary: Options{Data: "test", Size: 250}},
+		{name: "primary caption", primary: Options{Data: "https://example.com", Caption: "Scan me"}, secondary: secondary},
+		{name: "primary frame", primary: Options{Data: "https://example.com", Size: 400, FrameStyle: "scan-me-bottom"}, secondary: secondary},
+		{name: "empty secondary", primary: primary, secondary: Options{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := GenerateOverlay(tt.primary, tt.secondary); err == nil {
+				t.Error("GenerateOverlay() should fail")
+			}
+		})
+	}
+}
+
+func TestGenerateOverlay_PhysicalSize(t *testing.T) {
+	secondary := Options{Data: "test", Foreground: "black", Background: "white"}
+	// 30mm at 300 DPI is 354 pixels and a 1mm border 12 pixels
+	physical := Options{Data: "https://example.com", PhysicalSize: PhysicalSize{WidthMM: 30, BorderMM: 1, DPI: 300}}
+	pixels := Options{Data: "https://example.com", Size: 354, Border: 12}
+
+	got, err := GenerateOverlay(physical, secondary)
+	if err != nil {
+		t.Fatalf("GenerateOverlay() error = %v", err)
+	}
+	want, err := GenerateOverlay(pixels, secondary)
+	if err != nil {
+		t.Fatalf("GenerateOverlay() error = %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("GenerateOverlay() with a physical border differs from the same border in pixels")
+	}
+}
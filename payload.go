@@ -0,0 +1,246 @@
+package qrcode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"net/url"
+	"strings"
+)
+
+// MultiURLPayload serializes a set of URLs keyed by locale (e.g. "en", "de-AT") into a JSON
+// payload that a scanner app can use to pick the best match, e.g.
+//
+//	{"urls":{"de":"https://example.com/de","en":"https://example.com/en"}}
+//
+// Keys are emitted in sorted order so the same input always produces the same code.
+// Every URL must be an absolute http or https URL
+func MultiURLPayload(urls map[string]string) (string, error) {
+	if len(urls) == 0 {
+		return "", fmt.Errorf("at least one url is required")
+	}
+	for locale, rawURL := range urls {
+		if locale == "" {
+			return "", fmt.Errorf("locale is required for url %q", rawURL)
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return "", fmt.Errorf("invalid url for locale %q: %w", locale, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", fmt.Errorf("invalid url for locale %q: must be an absolute http(s) url", locale)
+		}
+	}
+
+	// Without HTML escaping, & < > stay literal instead of growing the code as \u0026 etc.
+	var payload bytes.Buffer
+	enc := json.NewEncoder(&payload)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(struct {
+		URLs map[string]string `json:"urls"`
+	}{urls}); err != nil {
+		return "", fmt.Errorf("failed to encode payload: %w", err)
+	}
+	return strings.TrimSuffix(payload.String(), "\n"), nil
+}
+
+// ContactInfo describes a contact to share through a QR code
+type ContactInfo struct {
+	// Name is the display name of the contact
+	Name string
+
+	// Phones are the contact's phone numbers
+	Phones []string
+
+	// Emails are the contact's email addresses
+	Emails []string
+
+	// URL is the contact's website
+	URL string
+
+	// Address is the contact's postal address
+	Address string
+
+	// Note is free-form text attached to the contact
+	Note string
+}
+
+// MECard encodes the contact in the compact MECARD format, e.g.
+//
+//	MECARD:N:John Doe;TEL:+15551234567;EMAIL:john@example.com;;
+//
+// Empty fields are omitted and reserved characters (\ ; , :) are backslash-escaped
+func (c ContactInfo) MECard() string {
+	var b strings.Builder
+	b.WriteString("MECARD:")
+	field := func(name, value string) {
+		if value == "" {
+			return
+		}
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(meCardEscaper.Replace(value))
+		b.WriteByte(';')
+	}
+	field("N", c.Name)
+	for _, phone := range c.Phones {
+		field("TEL", phone)
+	}
+	for _, email := range c.Emails {
+		field("EMAIL", email)
+	}
+	field("URL", c.URL)
+	field("ADR", c.Address)
+	field("NOTE", c.Note)
+	b.WriteByte(';')
+	return b.String()
+}
+
+// VCard describes a contact to share as a vCard, which scanners open as a new contact
+type VCard struct {
+	// Name is the formatted name of the contact
+	Name string
+
+	// Org is the contact's organization
+	Org string
+
+	// Title is the contact's job title
+	Title string
+
+	// Phones are the contact's phone numbers
+	Phones []string
+
+	// Emails are the contact's email addresses
+	Emails []string
+
+	// URL is the contact's website
+	URL string
+
+	// Address is the contact's postal address, written as the street of a single ADR field
+	Address string
+}
+
+// Encode encodes the contact as a vCard 3.0 with CRLF line endings, e.g.
+//
+//	BEGIN:VCARD
+//	VERSION:3.0
+//	N:;John Doe;;;
+//	FN:John Doe
+//	TEL:+15551234567
+//	END:VCARD
+//
+// N and FN, which vCard 3.0 requires, are always written; N is structured, with Name as the
+// given name. Other empty fields are omitted. Reserved characters (\ ; ,) are
+// backslash-escaped and newlines are written as \n
+func (v VCard) Encode() string {
+	var b strings.Builder
+	line := func(name, value string) {
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(value)
+		b.WriteString("\r\n")
+	}
+	field := func(name, value string) {
+		if value != "" {
+			line(name, vCardEscaper.Replace(value))
+		}
+	}
+	line("BEGIN", "VCARD")
+	line("VERSION", "3.0")
+	line("N", ";"+vCardEscaper.Replace(v.Name)+";;;")
+	line("FN", vCardEscaper.Replace(v.Name))
+	field("ORG", v.Org)
+	field("TITLE", v.Title)
+	for _, phone := range v.Phones {
+		field("TEL", phone)
+	}
+	for _, email := range v.Emails {
+		field("EMAIL", email)
+	}
+	field("URL", v.URL)
+	if v.Address != "" {
+		line("ADR", ";;"+vCardEscaper.Replace(v.Address)+";;;;")
+	}
+	line("END", "VCARD")
+	return b.String()
+}
+
+// WiFiData builds the WIFI: payload that phones (iOS camera, Android) recognize as network
+// credentials, e.g.
+//
+//	WIFI:T:WPA;S:Guest;P:secret;H:true;;
+//
+// auth is case-insensitive and one of WPA, WPA2, WPA3, SAE, WEP or nopass; empty or unknown
+// values mean WPA. WPA2 is written as WPA and WPA3 as SAE, the tokens scanners expect. An empty
+// password always means nopass, and nopass drops the password. Reserved characters (\ ; , :)
+// in ssid and password are backslash-escaped
+func WiFiData(ssid, password, auth string, hidden bool) string {
+	token := "WPA"
+	switch strings.ToUpper(auth) {
+	case "WPA3", "SAE":
+		token = "SAE"
+	case "WEP":
+		token = "WEP"
+	case "NOPASS":
+		token = "nopass"
+	}
+	if password == "" || token == "nopass" {
+		token = "nopass"
+		password = ""
+	}
+
+	var b strings.Builder
+	b.WriteString("WIFI:T:")
+	b.WriteString(token)
+	b.WriteString(";S:")
+	b.WriteString(meCardEscaper.Replace(ssid))
+	b.WriteByte(';')
+	if password != "" {
+		b.WriteString("P:")
+		b.WriteString(meCardEscaper.Replace(password))
+		b.WriteByte(';')
+	}
+	if hidden {
+		b.WriteString("H:true;")
+	}
+	b.WriteByte(';')
+	return b.String()
+}
+
+// meCardEscaper escapes reserved characters in MECARD-style payloads (MECARD and WIFI)
+var meCardEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, `:`, `\:`)
+
+// vCardEscaper escapes text values in vCard 3.0 (RFC 2426) properties
+var vCardEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// ChecksumWrapper returns a payload wrapper for Options.PayloadWrapper that prefixes data with
+// a version tag and its CRC-32 checksum in hex, e.g.
+//
+//	v1:3610a686:hello
+//
+// Use UnwrapChecksum with the same tag to validate and strip the envelope after scanning
+func ChecksumWrapper(tag string) func(data string) (string, error) {
+	return func(data string) (string, error) {
+		if tag == "" || strings.Contains(tag, ":") {
+			return "", fmt.Errorf("tag must be non-empty and must not contain ':'")
+		}
+		return fmt.Sprintf("%s:%08x:%s", tag, crc32.ChecksumIEEE([]byte(data)), data), nil
+	}
+}
+
+// UnwrapChecksum validates a payload produced by ChecksumWrapper(tag) and returns the original data
+func UnwrapChecksum(tag, payload string) (string, error) {
+	gotTag, rest, ok := strings.Cut(payload, ":")
+	if !ok || gotTag != tag {
+		return "", fmt.Errorf("payload does not start with tag %q", tag)
+	}
+	sum, data, ok := strings.Cut(rest, ":")
+	if !ok || len(sum) != 8 {
+		return "", fmt.Errorf("payload is missing its checksum")
+	}
+	if want := fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(data))); sum != want {
+		return "", fmt.Errorf("checksum mismatch: got %s, want %s", sum, want)
+	}
+	return data, nil
+}
@@ -0,0 +1,254 @@
+package qrcode
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMultiURLPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		urls    map[string]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "sorted locales",
+			urls: map[string]string{
+				"en": "https://example.com/en",
+				"de": "https://example.com/de",
+			},
+			want: `{"urls":{"de":"https://example.com/de","en":"https://example.com/en"}}`,
+		},
+		{
+			name: "query string kept literal",
+			urls: map[string]string{"en": "https://example.com/?a=1&b=2"},
+			want: `{"urls":{"en":"https://example.com/?a=1&b=2"}}`,
+		},
+		{
+			name:    "empty map",
+			urls:    map[string]string{},
+			wantErr: true,
+		},
+		{
+			name:    "empty locale",
+			urls:    map[string]string{"": "https://example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "relative url",
+			urls:    map[string]string{"en": "/en"},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported scheme",
+			urls:    map[string]string{"en": "javascript:alert(1)"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MultiURLPayload(tt.urls)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MultiURLPayload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("MultiURLPayload() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContactInfo_MECard(t *testing.T) {
+	tests := []struct {
+		name    string
+		contact ContactInfo
+		want    string
+	}{
+		{
+			name:    "name only",
+			contact: ContactInfo{Name: "John Doe"},
+			want:    "MECARD:N:John Doe;;",
+		},
+		{
+			name: "all fields",
+			contact: ContactInfo{
+				Name:    "John Doe",
+				Phones:  []string{"+15551234567", "+15557654321"},
+				Emails:  []string{"john@example.com"},
+				URL:     "https://example.com",
+				Address: "1 Main St, Springfield",
+				Note:    "Met at GopherCon",
+			},
+			want: `MECARD:N:John Doe;TEL:+15551234567;TEL:+15557654321;EMAIL:john@example.com;` +
+				`URL:https\://example.com;ADR:1 Main St\, Springfield;NOTE:Met at GopherCon;;`,
+		},
+		{
+			name:    "escaping",
+			contact: ContactInfo{Name: `A;B,C:D\E`},
+			want:    `MECARD:N:A\;B\,C\:D\\E;;`,
+		},
+		{
+			name:    "empty",
+			contact: ContactInfo{},
+			want:    "MECARD:;",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.contact.MECard(); got != tt.want {
+				t.Errorf("MECard() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVCard_Encode(t *testing.T) {
+	tests := []struct {
+		name string
+		card VCard
+		want []string
+	}{
+		{
+			name: "name only",
+			card: VCard{Name: "John Doe"},
+			want: []string{"BEGIN:VCARD", "VERSION:3.0", "N:;John Doe;;;", "FN:John Doe", "END:VCARD"},
+		},
+		{
+			name: "all fields",
+			card: VCard{
+				Name:    "John Doe",
+				Org:     "Example Inc.",
+				Title:   "Engineer",
+				Phones:  []string{"+15551234567", "+15557654321"},
+				Emails:  []string{"john@example.com", "jd@example.org"},
+				URL:     "https://example.com",
+				Address: "1 Main St, Springfield",
+			},
+			want: []string{
+				"BEGIN:VCARD", "VERSION:3.0", "N:;John Doe;;;", "FN:John Doe",
+				"ORG:Example Inc.", "TITLE:Engineer",
+				"TEL:+15551234567", "TEL:+15557654321",
+				"EMAIL:john@example.com", "EMAIL:jd@example.org",
+				"URL:https://example.com", `ADR:;;1 Main St\, Springfield;;;;`,
+				"END:VCARD",
+			},
+		},
+		{
+			name: "escaping",
+			card: VCard{Name: `A;B,C\D`, Org: "Line 1\nLine 2"},
+			want: []string{
+				"BEGIN:VCARD", "VERSION:3.0", `N:;A\;B\,C\\D;;;`, `FN:A\;B\,C\\D`, `ORG:Line 1\nLine 2`, "END:VCARD",
+			},
+		},
+		{
+			name: "empty optional fields omitted",
+			card: VCard{Name: "Jane", Phones: []string{""}, Emails: []string{}},
+			want: []string{"BEGIN:VCARD", "VERSION:3.0", "N:;Jane;;;", "FN:Jane", "END:VCARD"},
+		},
+		{
+			name: "without name",
+			card: VCard{Org: "Example Inc.", Phones: []string{"+15551234567"}},
+			want: []string{"BEGIN:VCARD", "VERSION:3.0", "N:;;;;", "FN:", "ORG:Example Inc.", "TEL:+15551234567", "END:VCARD"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.card.Encode()
+			if want := strings.Join(tt.want, "\r\n") + "\r\n"; got != want {
+				t.Errorf("Encode() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestChecksumWrapper(t *testing.T) {
+	wrapped, err := ChecksumWrapper("v1")("hello")
+	if err != nil {
+		t.Fatalf("ChecksumWrapper() error = %v", err)
+	}
+	if want := "v1:3610a686:hello"; wrapped != want {
+		t.Fatalf("ChecksumWrapper() = %s, want %s", wrapped, want)
+	}
+	if _, err := ChecksumWrapper("v:1")("hello"); err == nil {
+		t.Error("ChecksumWrapper() with ':' in the tag should fail")
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+		wantErr bool
+	}{
+		{name: "valid", payload: wrapped, want: "hello"},
+		{name: "data containing colons", payload: mustWrap(t, "a:b:c"), want: "a:b:c"},
+		{name: "wrong tag", payload: "v2:3610a686:hello", wantErr: true},
+		{name: "tampered data", payload: "v1:3610a686:hellO", wantErr: true},
+		{name: "missing checksum", payload: "v1:hello", wantErr: true},
+		{name: "no envelope", payload: "hello", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnwrapChecksum("v1", tt.payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnwrapChecksum() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("UnwrapChecksum() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func mustWrap(t *testing.T, data string) string {
+	t.Helper()
+	wrapped, err := ChecksumWrapper("v1")(data)
+	if err != nil {
+		t.Fatalf("ChecksumWrapper() error = %v", err)
+	}
+	return wrapped
+}
+
+func TestWiFiData(t *testing.T) {
+	tests := []struct {
+		name     string
+		ssid     string
+		password string
+		auth     string
+		hidden   bool
+		want     string
+	}{
+		{name: "wpa", ssid: "Guest", password: "secret", auth: "WPA", want: "WIFI:T:WPA;S:Guest;P:secret;;"},
+		{name: "wpa2 as wpa", ssid: "Guest", password: "secret", auth: "wpa2", want: "WIFI:T:WPA;S:Guest;P:secret;;"},
+		{name: "wpa3 as sae", ssid: "Guest", password: "secret", auth: "WPA3", want: "WIFI:T:SAE;S:Guest;P:secret;;"},
+		{name: "sae", ssid: "Guest", password: "secret", auth: "SAE", want: "WIFI:T:SAE;S:Guest;P:secret;;"},
+		{name: "wep", ssid: "Old", password: "12345", auth: "WEP", want: "WIFI:T:WEP;S:Old;P:12345;;"},
+		{name: "nopass", ssid: "Cafe", auth: "nopass", want: "WIFI:T:nopass;S:Cafe;;"},
+		{name: "default with password", ssid: "Guest", password: "secret", want: "WIFI:T:WPA;S:Guest;P:secret;;"},
+		{name: "default without password", ssid: "Cafe", want: "WIFI:T:nopass;S:Cafe;;"},
+		{name: "unknown auth as wpa", ssid: "Guest", password: "secret", auth: "WPA4", want: "WIFI:T:WPA;S:Guest;P:secret;;"},
+		{name: "nopass drops password", ssid: "Guest", password: "secret", auth: "nopass", want: "WIFI:T:nopass;S:Guest;;"},
+		{name: "wpa without password", ssid: "Guest", auth: "WPA", want: "WIFI:T:nopass;S:Guest;;"},
+		{name: "wep without password", ssid: "Guest", auth: "wep", want: "WIFI:T:nopass;S:Guest;;"},
+		{name: "sae without password", ssid: "Guest", auth: "WPA3", want: "WIFI:T:nopass;S:Guest;;"},
+		{name: "hidden", ssid: "Lab", password: "secret", auth: "WPA", hidden: true, want: "WIFI:T:WPA;S:Lab;P:secret;H:true;;"},
+		{
+			name:     "escaping",
+			ssid:     `My;Net,"A":B\C`,
+			password: `p;a,s:s\`,
+			auth:     "WPA",
+			want:     `WIFI:T:WPA;S:My\;Net\,"A"\:B\\C;P:p\;a\,s\:s\\;;`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WiFiData(tt.ssid, tt.password, tt.auth, tt.hidden); got != tt.want {
+				t.Errorf("WiFiData() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
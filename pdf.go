@@ -0,0 +1,188 @@
+package qrcode
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Contact sheet layout in PDF points: A4 pages with a half inch margin, and a caption line
+// below each code
+const (
+	sheetPageWidth   = 595
+	sheetPageHeight  = 842
+	sheetMargin      = 36
+	sheetCellPadding = 6
+	sheetLabelSize   = 9
+	sheetLabelHeight = 14
+)
+
+// GenerateContactSheetPDF lays out a code for each job in a grid of cols columns across A4 PDF
+// pages, each captioned with its map key. Jobs are placed in key order, and each code is
+// scaled to the cell width, so the job's Size only sets the image resolution. Labels use
+// Helvetica; characters outside Latin-1 are replaced with "?"
+func (g *Generator) GenerateContactSheetPDF(jobs map[string]Options, cols int) ([]byte, error) {
+	if cols <= 0 {
+		return nil, fmt.Errorf("columns must be positive")
+	}
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("at least one job is required")
+	}
+	labels := make([]string, 0, len(jobs))
+	for label := range jobs {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	cell := float64(sheetPageWidth-2*sheetMargin) / float64(cols)
+	side := cell - 2*sheetCellPadding
+	if side < 1 {
+		return nil, fmt.Errorf("%d columns do not fit on a page", cols)
+	}
+	rowHeight := cell + sheetLabelHeight
+	rows := int(float64(sheetPageHeight-2*sheetMargin) / rowHeight)
+	if rows < 1 {
+		return nil, fmt.Errorf("%d columns do not fit on a page", cols)
+	}
+	perPage := rows * cols
+
+	pdf := &pdfWriter{}
+	catalog, pages, font := pdf.reserve(), pdf.reserve(), pdf.reserve()
+	pdf.object(font, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+
+	var pageRefs []string
+	for first := 0; first < len(labels); first += perPage {
+		var content bytes.Buffer
+		var xobjects []string
+		for i, label := range labels[first:min(first+perPage, len(labels))] {
+			img, _, err := g.render(jobs[label], time.Now())
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate %q: %w", label, err)
+			}
+			imageObj, err := pdf.image(img)
+			if err != nil {
+				return nil, err
+			}
+			name := fmt.Sprintf("Im%d", i)
+			xobjects = append(xobjects, fmt.Sprintf("/%s %d 0 R", name, imageObj))
+
+			// PDF coordinates start at the bottom left of the page
+			x := sheetMargin + float64(i%cols)*cell + sheetCellPadding
+			top := sheetPageHeight - sheetMargin - float64(i/cols)*rowHeight - sheetCellPadding
+			fmt.Fprintf(&content, "q %.2f 0 0 %.2f %.2f %.2f cm /%s Do Q\n", side, side, x, top-side, name)
+			fmt.Fprintf(&content, "BT /F1 %d Tf %.2f %.2f Td (%s) Tj ET\n",
+				sheetLabelSize, x, top-side-sheetLabelHeight+4, pdfString(label))
+		}
+
+		contentObj := pdf.add(pdf.stream("", content.Bytes()))
+		page := pdf.add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 %d 0 R >> /XObject << %s >> >> /Contents %d 0 R >>",
+			pages, sheetPageWidth, sheetPageHeight, font, strings.Join(xobjects, " "), contentObj))
+		pageRefs = append(pageRefs, fmt.Sprintf("%d 0 R", page))
+	}
+	pdf.object(pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageRefs, " "), len(pageRefs)))
+	pdf.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	return pdf.finish(catalog), nil
+}
+
+// GenerateContactSheetPDF is a convenience function that creates a generator and lays out a
+// batch of QR codes on PDF pages
+func GenerateContactSheetPDF(jobs map[string]Options, cols int) ([]byte, error) {
+	g := New()
+	return g.GenerateContactSheetPDF(jobs, cols)
+}
+
+// pdfWriter assembles a PDF file from numbered objects
+type pdfWriter struct {
+	objects []string
+}
+
+// reserve allocates an object number whose body is set later with object
+func (w *pdfWriter) reserve() int {
+	w.objects = append(w.objects, "")
+	return len(w.objects)
+}
+
+// object sets the body of object n
+func (w *pdfWriter) object(n int, body string) {
+	w.objects[n-1] = body
+}
+
+// add appends an object with the given body and returns its number
+func (w *pdfWriter) add(body string) int {
+	n := w.reserve()
+	w.object(n, body)
+	return n
+}
+
+// stream returns a stream object body with the extra dictionary entries and data
+func (w *pdfWriter) stream(entries string, data []byte) string {
+	return fmt.Sprintf("<< %s/Length %d >>\nstream\n%s\nendstream", entries, len(data), data)
+}
+
+// image adds img as a Flate compressed RGB image XObject and returns its object number
+func (w *pdfWriter) image(img image.Image) (int, error) {
+	bounds := img.Bounds()
+	var data bytes.Buffer
+	zw := zlib.NewWriter(&data)
+	row := make([]byte, 0, 3*bounds.Dx())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row = row[:0]
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			// Composite translucent pixels over white paper
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			a := uint32(c.A)
+			over := func(v uint8) byte { return byte((uint32(v)*a + 255*(255-a)) / 255) }
+			row = append(row, over(c.R), over(c.G), over(c.B))
+		}
+		if _, err := zw.Write(row); err != nil {
+			return 0, fmt.Errorf("failed to compress image: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return 0, fmt.Errorf("failed to compress image: %w", err)
+	}
+	entries := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode ",
+		bounds.Dx(), bounds.Dy())
+	return w.add(w.stream(entries, data.Bytes())), nil
+}
+
+// finish serializes all objects with the cross-reference table and trailer
+func (w *pdfWriter) finish(root int) []byte {
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(w.objects))
+	for i, body := range w.objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, body)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(w.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.objects)+1, root, xref)
+	return out.Bytes()
+}
+
+// pdfString escapes s for a PDF literal string in WinAnsiEncoding, replacing control
+// characters and characters outside Latin-1 with "?"
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || (r >= 0x7f && r < 0xa0) || r > 0xff:
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
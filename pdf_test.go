@@ -0,0 +1,86 @@
+package qrcode
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGenerateContactSheetPDF(t *testing.T) {
+	// Four columns fit five rows on an A4 page, so 21 jobs need two pages
+	jobs := make(map[string]Options)
+	for i := 0; i < 21; i++ {
+		jobs[fmt.Sprintf("ASSET-%02d", i)] = Options{Data: fmt.Sprintf("https://example.com/asset/%d", i), Size: 100}
+	}
+	jobs["Dock (A)\\1"] = Options{Data: "https://example.com/dock", Size: 100}
+	delete(jobs, "ASSET-20")
+
+	data, err := GenerateContactSheetPDF(jobs, 4)
+	if err != nil {
+		t.Fatalf("GenerateContactSheetPDF() error = %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("GenerateContactSheetPDF() should return a PDF document")
+	}
+
+	// Every cross-reference entry must point at the start of its object
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	if startxref == nil {
+		t.Fatal("PDF has no startxref")
+	}
+	xref, _ := strconv.Atoi(string(startxref[1]))
+	lines := strings.Split(string(data[xref:]), "\n")
+	count, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	for n := 1; n < count; n++ {
+		offset, _ := strconv.Atoi(strings.Fields(lines[2+n])[0])
+		if want := fmt.Sprintf("%d 0 obj\n", n); !bytes.HasPrefix(data[offset:], []byte(want)) {
+			t.Fatalf("xref entry %d points at %q", n, data[offset:offset+10])
+		}
+	}
+
+	if !bytes.Contains(data, []byte("/Type /Pages /Kids [")) || !bytes.Contains(data, []byte("/Count 2 >>")) {
+		t.Error("PDF should have two pages")
+	}
+	for _, label := range []string{"(ASSET-00)", "(ASSET-19)", `(Dock \(A\)\\1)`} {
+		if !bytes.Contains(data, []byte(label)) {
+			t.Errorf("PDF content is missing label %s", label)
+		}
+	}
+
+	// Images are Flate compressed RGB rows of the rendered 100px codes
+	image := regexp.MustCompile(`(?s)/Width (\d+) /Height (\d+) .*?/Length (\d+) >>\nstream\n`).FindSubmatchIndex(data)
+	if image == nil {
+		t.Fatal("PDF has no image XObject")
+	}
+	length, _ := strconv.Atoi(string(data[image[6]:image[7]]))
+	zr, err := zlib.NewReader(bytes.NewReader(data[image[1] : image[1]+length]))
+	if err != nil {
+		t.Fatalf("image stream is not zlib compressed: %v", err)
+	}
+	pixels, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("failed to decompress image: %v", err)
+	}
+	if string(data[image[2]:image[3]]) != "100" || len(pixels) != 100*100*3 {
+		t.Errorf("image has %d bytes for width %s, want 100x100 RGB", len(pixels), data[image[2]:image[3]])
+	}
+
+	for _, invalid := range []struct {
+		jobs map[string]Options
+		cols int
+	}{
+		{jobs, 0},
+		{jobs, 1000},
+		{nil, 4},
+		{map[string]Options{"empty": {}}, 4},
+	} {
+		if _, err := GenerateContactSheetPDF(invalid.jobs, invalid.cols); err == nil {
+			t.Errorf("GenerateContactSheetPDF() with %d jobs in %d columns should fail", len(invalid.jobs), invalid.cols)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package qrcode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// withTextChunks inserts a tEXt chunk for each metadata entry, in key order, right after the
+// IHDR chunk of the encoded PNG. Keys must be 1-79 characters and keys and values must be
+// Latin-1 without NUL characters, as required by the PNG specification
+func withTextChunks(data []byte, metadata map[string]string) ([]byte, error) {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var chunks bytes.Buffer
+	for _, key := range keys {
+		keyword, err := latin1(key)
+		if err != nil || len(keyword) == 0 || len(keyword) > 79 {
+			return nil, fmt.Errorf("invalid metadata key %q: must be 1-79 Latin-1 characters", key)
+		}
+		text, err := latin1(metadata[key])
+		if err != nil {
+			return nil, fmt.Errorf("invalid metadata value for key %q: %w", key, err)
+		}
+		chunk := append(append(keyword, 0), text...)
+		if err := writeChunk(&chunks, "tEXt", chunk); err != nil {
+			return nil, err
+		}
+	}
+
+	// The IHDR chunk directly follows the signature: length, type, 13 data bytes and CRC
+	ihdrEnd := len(pngSignature) + 8 + int(binary.BigEndian.Uint32(data[len(pngSignature):])) + 4
+	out := make([]byte, 0, len(data)+chunks.Len())
+	out = append(out, data[:ihdrEnd]...)
+	out = append(out, chunks.Bytes()...)
+	return append(out, data[ihdrEnd:]...), nil
+}
+
+// latin1 converts s to Latin-1 bytes, failing for NUL and characters outside Latin-1
+func latin1(s string) ([]byte, error) {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r == 0 || r > 0xff {
+			return nil, fmt.Errorf("character %q is not allowed", r)
+		}
+		b = append(b, byte(r))
+	}
+	return b, nil
+}
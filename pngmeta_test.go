@@ -0,0 +1,95 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestGeneratePNG_Metadata(t *testing.T) {
+	data, err := GeneratePNG(Options{
+		Data: "https://example.com",
+		Metadata: map[string]string{
+			"Payload":       "https://example.com",
+			"Creation Time": "2024-01-02T15:04:05Z",
+		},
+	})
+	if err != nil {
+		t.Fatalf("GeneratePNG() error = %v", err)
+	}
+
+	chunks := apngChunks(t, data)
+	if len(chunks) < 4 || chunks[0] != "IHDR" || chunks[1] != "tEXt" || chunks[2] != "tEXt" {
+		t.Fatalf("chunks = %v, want IHDR followed by two tEXt chunks", chunks)
+	}
+	first := bytes.Index(data, []byte("Creation Time\x002024-01-02T15:04:05Z"))
+	second := bytes.Index(data, []byte("Payload\x00https://example.com"))
+	if first < 0 || second < 0 || first > second {
+		t.Error("tEXt chunks should hold the metadata in key order")
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("GeneratePNG() with metadata returned invalid PNG: %v", err)
+	}
+
+	plain, err := GeneratePNG(Options{Data: "https://example.com"})
+	if err != nil {
+		t.Fatalf("GeneratePNG() error = %v", err)
+	}
+	for _, chunk := range apngChunks(t, plain) {
+		if chunk == "tEXt" {
+			t.Error("GeneratePNG() without metadata should not write tEXt chunks")
+		}
+	}
+
+	tests := []struct {
+		name     string
+		metadata map[string]string
+	}{
+		{name: "empty key", metadata: map[string]string{"": "value"}},
+		{name: "long key", metadata: map[string]string{strings.Repeat("k", 80): "value"}},
+		{name: "NUL in value", metadata: map[string]string{"Comment": "a\x00b"}},
+		{name: "non Latin-1 value", metadata: map[string]string{"Comment": "日本"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := GeneratePNG(Options{Data: "test", Metadata: tt.metadata}); err == nil {
+				t.Error("GeneratePNG() with invalid metadata should fail")
+			}
+		})
+	}
+}
+
+func TestGenerateUnderSize_Metadata(t *testing.T) {
+	data, err := GenerateUnderSize(Options{
+		Data:     "https://example.com",
+		Metadata: map[string]string{"Payload": "https://example.com"},
+	}, 20000, "png")
+	if err != nil {
+		t.Fatalf("GenerateUnderSize() error = %v", err)
+	}
+	if chunks := apngChunks(t, data); len(chunks) < 2 || chunks[1] != "tEXt" {
+		t.Errorf("chunks = %v, want a tEXt chunk after IHDR", chunks)
+	}
+	if !bytes.Contains(data, []byte("Payload\x00https://example.com")) {
+		t.Error("GenerateUnderSize() should keep the metadata")
+	}
+}
+
+func TestGenerateOverlay_Metadata(t *testing.T) {
+	data, err := GenerateOverlay(Options{
+		Data:     "https://example.com",
+		Size:     330,
+		Border:   4,
+		Metadata: map[string]string{"Payload": "https://example.com"},
+	}, Options{Data: "test"})
+	if err != nil {
+		t.Fatalf("GenerateOverlay() error = %v", err)
+	}
+	if !bytes.Contains(data, []byte("Payload\x00https://example.com")) {
+		t.Error("GenerateOverlay() should keep the primary metadata")
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("GenerateOverlay() with metadata returned invalid PNG: %v", err)
+	}
+}
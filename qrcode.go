@@ -2,18 +2,33 @@ package qrcode
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
+	"image/color/palette"
 	"image/draw"
+	"image/gif"
+	"image/jpeg"
 	"image/png"
+	"io"
 	"math"
 	"net/http"
+	"os"
+	"reflect"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/disintegration/imaging"
 	"github.com/skip2/go-qrcode"
-	_ "golang.org/x/image/webp"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+	"golang.org/x/image/webp"
 )
 
 // Options represents the configuration options for QR code generation
@@ -21,59 +36,1243 @@ type Options struct {
 	// Data is the content to encode in the QR code (required)
 	Data string
 
+	// PayloadWrapper, when set, transforms Data before encoding, e.g. to add an envelope
+	// expected by a scanner app (see ChecksumWrapper)
+	PayloadWrapper func(data string) (string, error)
+
+	// UTF8BOM prefixes the encoded data with a UTF-8 byte order mark, which some scanners need
+	// to detect non-ASCII content while others show it as garbage. Default: false
+	UTF8BOM bool
+
 	// Size is the QR code dimensions in pixels (default: 300)
 	Size int
 
+	// PhysicalSize, when WidthMM is set, computes Size (and Border, if BorderMM is set) from
+	// print dimensions at the given DPI, overriding the pixel values
+	PhysicalSize PhysicalSize
+
 	// Foreground is the foreground color (QR code pattern)
-	// Supports: rgb(r,g,b), rgba(r,g,b,a), or named colors (black, white, red, green, blue)
-	// Default: black
+	// Supports: rgb(r,g,b), rgba(r,g,b,a), #RGB, #RRGGBB, #RRGGBBAA, or named colors (black,
+	// white, red, green, blue). Default: black
 	Foreground string
 
-	// Background is the background color
-	// Supports: rgb(r,g,b), rgba(r,g,b,a), or named colors (black, white, red, green, blue)
-	// Default: white
-	Background string
+	// Background is the background color
+	// Supports the same formats as Foreground, plus "transparent" (as does rgba(r,g,b,0)) to
+	// keep light modules at alpha 0. Default: white
+	Background string
+
+	// DarkMode defaults empty Foreground/Background to a light gray foreground on a near-black
+	// background. If only one color is set, the other defaults to the dark mode color when it
+	// contrasts enough with it, and to black or white otherwise. Explicit colors always win
+	DarkMode bool
+
+	// Error is the error correction level: L (Low ~7%), M (Medium ~15%), Q (High ~25%), H (Highest ~30%)
+	// Default: M
+	Error string
+
+	// ErrorLevel is the typed error correction level. When set it takes precedence over Error
+	ErrorLevel ErrorLevel
+
+	// Border is the border width in pixels (0 = no border)
+	// Default: 0
+	Border int
+
+	// ForceExactSize makes the code exactly Size x Size pixels: Border no longer enlarges it and
+	// codes with more modules than Size pixels are scaled down. Captions, letterboxing and crop
+	// marks still extend the canvas beyond Size
+	ForceExactSize bool
+
+	// SnapToModule rounds Size up to the nearest multiple of the module count (including the
+	// quiet zone), so every module is a whole number of pixels and no resampling blur occurs
+	// Cannot be combined with ForceExactSize
+	SnapToModule bool
+
+	// Invert swaps the foreground and background colors after encoding, drawing light modules
+	// on a dark background. Many but not all scanners read inverted codes, so test your targets
+	Invert bool
+
+	// MinVersion is the smallest QR version (1-40) to use, padding short data into a larger symbol
+	// Default: 0 (no lower bound)
+	MinVersion int
+
+	// MaxVersion is the largest QR version (1-40) allowed; generation fails if the data needs more
+	// Default: 0 (no upper bound)
+	MaxVersion int
+
+	// ReserveCenter clears a central square of this percentage of the code size (0-100),
+	// rounded out to whole modules, to the background color, leaving room for a logo
+	// composited later. Use a high error correction level. Default: 0 (nothing reserved)
+	ReserveCenter float64
+
+	// LogoURL is the URL to a logo image to embed in the center of the QR code
+	LogoURL string
+
+	// LogoPath is a local file to read the logo from instead of fetching LogoURL
+	LogoPath string
+
+	// LogoReader supplies the logo image data directly. It is read once, so use a fresh reader
+	// for every generation. Logo sources take precedence in the order LogoReader, LogoPath,
+	// LogoURL
+	LogoReader io.Reader
+
+	// AllowedLogoSchemes lists the URL schemes LogoURL may use (default: http and https)
+	AllowedLogoSchemes []string
+
+	// AllowedLogoHosts, when set, lists the only hosts LogoURL may point at. Listed hosts may
+	// be internal; unlisted hosts are always refused if they are localhost or resolve to a
+	// loopback, private or link-local address
+	AllowedLogoHosts []string
+
+	// LogoTimeout limits fetching LogoURL, including reading the response (default: 10s, or
+	// HTTPClient's own Timeout when it has one)
+	LogoTimeout time.Duration
+
+	// HTTPClient fetches LogoURL instead of the built-in client. Its transport is used as is,
+	// so only the URL and redirects are checked against the logo allowlists, not the
+	// addresses they resolve to
+	HTTPClient *http.Client
+
+	// LogoFormat names the logo's image format ("png", "jpeg", "gif", "bmp", "tiff" or "webp")
+	// to decode it with that decoder instead of detecting the format from its content
+	LogoFormat string
+
+	// LogoSize is the logo size as a percentage of the QR code (default: 20.0)
+	LogoSize float64
+
+	// LogoAuto overrides LogoSize with the largest logo the error correction level can recover
+	// from: it covers at most half of the level's recovery capacity of the symbol area and
+	// clears the finder patterns. The chosen size is reported in Info.LogoSize
+	LogoAuto bool
+
+	// LogoNoResize composites the logo at its native size instead of fitting it to LogoSize
+	// The logo must not be larger than the QR code
+	LogoNoResize bool
+
+	// LogoStretch resizes the logo to exactly fill the LogoSize box instead of preserving its
+	// aspect ratio. Ignored when LogoNoResize is set
+	LogoStretch bool
+
+	// LogoStrict fails generation when the logo would overlap a finder pattern (or its
+	// separator). By default such a logo is shrunk until it clears the finders
+	LogoStrict bool
+
+	// LogoCutout clears the modules under the logo to LogoBackground before compositing it,
+	// rounding the logo box outward to whole modules so the cleared area follows the grid
+	LogoCutout bool
+
+	// LogoBackground is the color of the cleared area when LogoCutout is set (default: background)
+	LogoBackground string
+
+	// LogoTint recolors the logo before compositing: dark pixels take this color and lighter
+	// ones blend towards white by their luminance, keeping their alpha. Default: unchanged
+	LogoTint string
+
+	// LogoOffsetX/LogoOffsetY shift the logo from the center by the given number of pixels
+	// The shifted logo must stay within the QR code
+	LogoOffsetX int
+	LogoOffsetY int
+
+	// GradientStart is the start color for gradient effect
+	// Requires GradientEnd to be set
+	GradientStart string
+
+	// GradientEnd is the end color for gradient effect
+	// Requires GradientStart to be set
+	GradientEnd string
+
+	// GradientType is the type of gradient: "linear", "radial" or "sequential", which colors
+	// each data module by its position in the codeword placement order (zig-zag from the
+	// bottom right), with function patterns in the start color (default: "linear")
+	GradientType string
+
+	// GradientCenterX/GradientCenterY position the center of a radial gradient as fractions
+	// (0-1) of the width and height. Default: 0.5/0.5, used when both are 0, so (0, 0) means
+	// centered rather than the top left corner; use e.g. 0.001/0.001 for that corner
+	GradientCenterX float64
+	GradientCenterY float64
+
+	// GradientColorSpace is the color space gradients interpolate in: "srgb" mixes the raw
+	// color values, "linear" mixes linear-light values for smoother midtones between vivid
+	// colors. Default: "srgb"
+	GradientColorSpace string
+
+	// GradientCorners, when set, colors dark modules by bilinear interpolation of four corner
+	// colors (top left, top right, bottom left, bottom right), overriding GradientStart and
+	// GradientEnd. All four must be valid colors
+	GradientCorners [4]string
+
+	// GradientEdgeFade (0-1) fades the gradient toward the background with distance from the
+	// center, reaching 1-GradientEdgeFade opacity at the edges; with a transparent background
+	// the faded modules become transparent. Default: 0 (no fade)
+	GradientEdgeFade float64
+
+	// LightModuleColor is the color of light modules inside the symbol, leaving the quiet zone
+	// in the background color, e.g. to tint the code area. Default: background
+	LightModuleColor string
+
+	// BackgroundWatermark is text tiled faintly across the light modules and quiet zone, behind
+	// the dark modules, e.g. "VOID" for anti-counterfeiting. It is drawn with CaptionFont
+	BackgroundWatermark string
+
+	// WatermarkColor is the color of the watermark text (default: foreground)
+	WatermarkColor string
+
+	// WatermarkOpacity is the opacity of the watermark text, at most 0.2 so light modules stay
+	// light enough to scan. Default: 0.08, used when 0
+	WatermarkOpacity float64
+
+	// EyeColor is the color of the finder patterns ("eyes") in the corners, overriding the
+	// gradient so eyes stay solid. Default: foreground/gradient like data modules
+	EyeColor string
+
+	// AlignmentColor is the color of alignment patterns (the smaller squares in version 2+ codes)
+	// Supports the same formats as Foreground. Default: foreground/gradient like data modules
+	AlignmentColor string
+
+	// TimingColor is the color of the timing patterns (the alternating lines between finders)
+	// Supports the same formats as Foreground. Default: foreground/gradient like data modules
+	TimingColor string
+
+	// EyeBallShape is the shape of the 3x3 center of each finder pattern: "square" or "circle"
+	// Default: square
+	EyeBallShape string
+
+	// ModuleShape is the shape of dark modules: "square" or "bevel", a two-tone 3D edge made by
+	// lightening the top/left and darkening the bottom/right of each module. Default: square
+	ModuleShape string
+
+	// ModuleDrawer, when set, draws the shape of each dark module (see SquareDrawer,
+	// CircleDrawer and RoundedDrawer). Default: square modules
+	ModuleDrawer ModuleDrawer
+
+	// BackgroundPanel fills the code and its quiet zone with a rounded panel of PanelColor, leaving
+	// the background color in the corners outside it, for a "card" look with square modules
+	BackgroundPanel bool
+
+	// PanelColor is the fill color of the background panel; required with BackgroundPanel
+	PanelColor string
+
+	// PanelRadius is the corner radius of the background panel in pixels, at most the quiet zone
+	// width so the symbol is never cut. Default: half the quiet zone width
+	PanelRadius int
+
+	// SVGUseClasses makes GenerateSVG mark modules with CSS classes (qr-dark, qr-light, qr-eye)
+	// instead of inline fill attributes, so page styles can recolor them
+	SVGUseClasses bool
+
+	// SVGMergePath makes GenerateSVG draw all dark modules as a single <path> (one per class
+	// with SVGUseClasses) instead of one <rect> each, which greatly reduces the size of dense codes
+	SVGMergePath bool
+
+	// FrameStyle wraps the code in a decorative frame with a label below it, leaving the code
+	// and its quiet zone intact: "scan-me-bottom" (a band with a pointer above the label strip)
+	// or "rounded-badge" (a rounded badge). Default: no frame
+	FrameStyle string
+
+	// FrameLabel is the frame label text, drawn with CaptionFont (default: "SCAN ME")
+	FrameLabel string
+
+	// FrameColor is the color of the frame (default: foreground color)
+	FrameColor string
+
+	// FrameLabelColor is the color of the label text on the frame (default: background color)
+	FrameLabelColor string
+
+	// CutLine returns a die-cut outline of the image in Info.CutLine, as an SVG document of the
+	// same dimensions, for cutting stickers. It follows the rounded corners of a rounded-badge
+	// frame or a background panel. Banner, Caption, AspectRatio, CropMarks and Clip are not
+	// supported with it
+	CutLine bool
+
+	// Banner, when set, adds a banner with an app icon and text to the right of the code (and
+	// its frame), keeping the quiet zone. Default: no banner
+	Banner *Banner
+
+	// Caption is a text line (e.g. "Scan me") drawn centered in a strip added below the code
+	Caption string
+
+	// ShowText captions the code with Data, as given before PayloadWrapper, so the payload is
+	// human-readable; text too wide for the code is shortened with "...". An explicit Caption
+	// takes precedence
+	ShowText bool
+
+	// CaptionColor is the caption text color (default: foreground color)
+	CaptionColor string
+
+	// CaptionHeight is the height in pixels of the caption strip (default: 40)
+	CaptionHeight int
+
+	// CaptionFont is TTF/OTF font data used for the caption, sized to half the caption height
+	// Default: the built-in 7x13 bitmap font
+	CaptionFont []byte
+
+	// AspectRatio pads the image to a "W:H" aspect ratio (e.g. "16:9") with letterbox bars,
+	// keeping the code centered. Default: no padding
+	AspectRatio string
+
+	// LetterboxColor is the color of the letterbox bars (default: background color)
+	LetterboxColor string
+
+	// Clip, when non-empty, returns only this sub-rectangle of the rendered image (e.g. for
+	// progressive reveals or tiling). It is intersected with the image bounds
+	Clip image.Rectangle
+
+	// CropMarks extends the canvas by a margin and draws thin crop marks at the corners for
+	// trimming printed codes. The marks stay outside the image and never touch the code
+	CropMarks bool
+
+	// CropMarkColor is the color of the crop marks (default: foreground color)
+	CropMarkColor string
+
+	// PreviewCheckerboard composites the result over a gray checkerboard so transparent areas
+	// are visible in editors. Leave unset to get the true transparent output
+	PreviewCheckerboard bool
+
+	// Metadata is written into PNG output as tEXt chunks (e.g. "Comment", "Creation Time"),
+	// in key order and without affecting the image. Keys must be 1-79 characters; keys and
+	// values must be Latin-1 without NUL characters
+	Metadata map[string]string
+
+	// ColorModel sets the concrete type of the final image, and so the PNG color type: "rgba",
+	// "nrgba", "gray" (alpha is discarded), "paletted" (exact palette for up to 256 colors,
+	// nearest Plan 9 palette color otherwise) or "monochrome" (1-bit black and white, e.g. for
+	// thermal printers). Default: whatever the pipeline produced
+	ColorModel string
+
+	// MonochromeThreshold is the luminance (1-255) below which pixels turn black in the
+	// "monochrome" color model; raise it for heavier output. Default: 128, used when 0
+	MonochromeThreshold uint8
+
+	// Fast encodes go-qrcode's native image directly, skipping all post-processing
+	// Only Data, Size, colors, Error, Border, ForceExactSize and version bounds apply; all other
+	// styling and layout options (gradients, logos, module colors, captions, previews) are
+	// ignored when set
+	Fast bool
+
+	// shortened records that Data has already been through the generator's Shortener, so entry
+	// points that encode the same code several times call it only once
+	shortened bool
+}
+
+// PhysicalSize describes the printed dimensions of a QR code
+type PhysicalSize struct {
+	// WidthMM is the printed width of the code in millimeters
+	WidthMM float64
+
+	// BorderMM is the border width in millimeters, converted like Border in pixels
+	BorderMM float64
+
+	// DPI is the print resolution in dots per inch
+	DPI int
+}
+
+// pixels converts mm millimeters to pixels at the DPI of p
+func (p PhysicalSize) pixels(mm float64) int {
+	return int(math.Round(mm / 25.4 * float64(p.DPI)))
+}
+
+// resolvePhysicalSize sets opts.Size and opts.Border from opts.PhysicalSize, if set
+func resolvePhysicalSize(opts *Options) error {
+	p := opts.PhysicalSize
+	if p.WidthMM == 0 {
+		return nil
+	}
+	if p.WidthMM < 0 || p.BorderMM < 0 || p.DPI <= 0 {
+		return fmt.Errorf("physical size needs a positive width and DPI and a non-negative border")
+	}
+	opts.Size = max(1, p.pixels(p.WidthMM))
+	if p.BorderMM > 0 {
+		opts.Border = max(1, p.pixels(p.BorderMM))
+	}
+	opts.PhysicalSize = PhysicalSize{}
+	return nil
+}
+
+// Info describes the symbol behind a generated QR code
+type Info struct {
+	// Version is the QR version (1-40) selected for the data
+	Version int
+
+	// Modules is the number of modules per side, excluding the quiet zone
+	Modules int
+
+	// RecoveryLevel is the error correction level the symbol was encoded with (L, M, Q or H),
+	// which is M when Options.Error is empty or unrecognized
+	RecoveryLevel string
+
+	// Size is the side of the code in pixels, including the quiet zone but not captions,
+	// letterboxing or crop marks. With SnapToModule it is the rounded size
+	Size int
+
+	// LogoSize is the logo size as a percentage of the code, as chosen by LogoAuto or set with
+	// LogoSize, or 0 without a logo
+	LogoSize float64
+
+	// CutLine is an SVG document with the die-cut outline of the image when Options.CutLine
+	// is set, or nil
+	CutLine []byte
+
+	// Fingerprint is the hex SHA-256 of the final image's dimensions and 8-bit non-premultiplied
+	// RGBA pixels, so it changes only when the code looks different, whatever the encoding
+	Fingerprint string
+}
+
+// Pipeline events reported to Generator.OnEvent
+const (
+	// EventStart is reported when generation begins
+	EventStart = "start"
+
+	// EventEncoded is reported once the data has been encoded into a QR symbol
+	EventEncoded = "encoded"
+
+	// EventLogoFetched is reported after the logo has been fetched and composited
+	EventLogoFetched = "logo_fetched"
+
+	// EventDone is reported after the final image has been encoded
+	EventDone = "done"
+)
+
+// Generator provides QR code generation functionality
+type Generator struct {
+	// OnEvent, when set, is called at pipeline milestones (see the Event constants) with
+	// metadata including "elapsed", the time.Duration since generation started
+	OnEvent func(event string, meta map[string]any)
+
+	// Defaults supplies the value of every option left at its zero value in a call, so common
+	// settings need not be repeated. A call cannot reset an option to its zero value (e.g. a
+	// bool back to false or a color back to the built-in default) when Defaults sets it.
+	// Defaults cannot set LogoReader, as a reader is consumed by the first generation; use
+	// LogoPath or LogoURL instead
+	Defaults Options
+
+	// Shortener, when set, rewrites Data before it is encoded, e.g. to shorten long URLs into
+	// less dense codes. It runs before PayloadWrapper and ShowText, and an error aborts
+	// generation. It is called once per code, even by calls such as GenerateUnderSize and
+	// GenerateResponsive that encode the code several times
+	Shortener func(data string) (string, error)
+
+	// MaxPixels limits the width*height of the image canvas, including frames, banners,
+	// captions, letterboxing and crop marks, to guard the per-pixel gradient and logo work
+	// against pathological sizes. Default: 16777216 (4096x4096), used when 0; negative disables it
+	MaxPixels int
+
+	generated    atomic.Uint64
+	logoFailures atomic.Uint64
+	bytesOut     atomic.Uint64
+}
+
+// Metrics is a snapshot of a Generator's counters, suitable for exporting to a metrics system
+type Metrics struct {
+	// CodesGenerated is the number of QR codes successfully generated
+	CodesGenerated uint64
+
+	// LogoFetchFailures is the number of logos that could not be fetched or decoded
+	LogoFetchFailures uint64
+
+	// BytesOut is the total size of all generated images in bytes
+	BytesOut uint64
+}
+
+// Metrics returns a snapshot of the generator's counters. It is safe for concurrent use
+func (g *Generator) Metrics() Metrics {
+	return Metrics{
+		CodesGenerated:    g.generated.Load(),
+		LogoFetchFailures: g.logoFailures.Load(),
+		BytesOut:          g.bytesOut.Load(),
+	}
+}
+
+// withDefaults returns opts with every zero-valued field taken from g.Defaults, except readers,
+// which a single generation consumes (see Generator.prepare)
+func (g *Generator) withDefaults(opts Options) Options {
+	merged := reflect.ValueOf(&opts).Elem()
+	defaults := reflect.ValueOf(g.Defaults)
+	for i := 0; i < merged.NumField(); i++ {
+		if merged.Type().Field(i).Type == readerType {
+			continue
+		}
+		if merged.Field(i).CanSet() && merged.Field(i).IsZero() {
+			merged.Field(i).Set(defaults.Field(i))
+		}
+	}
+	return opts
+}
+
+// readerType is the type of Options fields that withDefaults never copies from g.Defaults
+var readerType = reflect.TypeFor[io.Reader]()
+
+// New creates a new QR code generator
+func New() *Generator {
+	return &Generator{}
+}
+
+// GeneratePNG generates a QR code as a PNG image byte array. Unlike GenerateWithInfo it does
+// not fingerprint the image
+func (g *Generator) GeneratePNG(opts Options) ([]byte, error) {
+	var out bytes.Buffer
+	if err := g.GeneratePNGTo(&out, opts); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// GeneratePNGReader generates a QR code as a PNG and returns a reader over the encoded bytes
+// together with their length, e.g. for setting Content-Length before streaming a response
+func (g *Generator) GeneratePNGReader(opts Options) (io.ReadCloser, int, error) {
+	data, err := g.GeneratePNG(opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), len(data), nil
+}
+
+// GeneratePNGTo generates a QR code and encodes the PNG straight into w, without holding the
+// encoded image in memory (unless Metadata chunks have to be inserted)
+func (g *Generator) GeneratePNGTo(w io.Writer, opts Options) error {
+	if w == nil {
+		return fmt.Errorf("writer is required")
+	}
+	_, _, err := g.generate(w, opts, writePNG)
+	return err
+}
+
+// GenerateWithInfo generates a QR code as a PNG image byte array and describes the encoded symbol
+func (g *Generator) GenerateWithInfo(opts Options) ([]byte, Info, error) {
+	var out bytes.Buffer
+	img, info, err := g.generate(&out, opts, writePNG)
+	if err != nil {
+		return nil, Info{}, err
+	}
+	info.Fingerprint = imageFingerprint(img)
+	return out.Bytes(), info, nil
+}
+
+// generate runs the pipeline for opts and writes the image to w with encode, reporting the
+// start and done events and recording the output in the generator's metrics
+func (g *Generator) generate(w io.Writer, opts Options, encode func(io.Writer, image.Image, Options) error) (image.Image, Info, error) {
+	opts = g.withDefaults(opts)
+	start := time.Now()
+	if g.OnEvent != nil {
+		g.OnEvent(EventStart, map[string]any{"elapsed": time.Duration(0)})
+	}
+
+	img, info, err := g.render(opts, start)
+	if err != nil {
+		return nil, Info{}, err
+	}
+	cw := &countingWriter{w: w}
+	if err := encode(cw, img, opts); err != nil {
+		return nil, Info{}, err
+	}
+	g.generated.Add(1)
+	g.bytesOut.Add(uint64(cw.n))
+	if g.OnEvent != nil {
+		g.OnEvent(EventDone, map[string]any{"elapsed": time.Since(start), "bytes": cw.n})
+	}
+	return img, info, nil
+}
+
+// writePNG encodes img as a PNG with the Metadata of opts into w
+func writePNG(w io.Writer, img image.Image, opts Options) error {
+	if len(opts.Metadata) == 0 {
+		if err := png.Encode(w, img); err != nil {
+			return fmt.Errorf("failed to encode png: %w", err)
+		}
+		return nil
+	}
+	data, err := encodePNG(img)
+	if err != nil {
+		return err
+	}
+	if data, err = withTextChunks(data, opts.Metadata); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write png: %w", err)
+	}
+	return nil
+}
+
+// countingWriter counts the bytes written to w
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
+
+// render runs the generation pipeline for opts and returns the final image
+func (g *Generator) render(opts Options, start time.Time) (image.Image, Info, error) {
+	opts = g.withDefaults(opts)
+	qr, err := g.prepare(&opts)
+	if err != nil {
+		return nil, Info{}, err
+	}
+	if opts.LogoAuto {
+		if opts.LogoNoResize {
+			return nil, Info{}, fmt.Errorf("logo auto sizing cannot be combined with logo no resize")
+		}
+		opts.LogoSize = autoLogoSize(qr)
+	}
+	info := Info{
+		Version:       qr.VersionNumber,
+		Modules:       symbolSize(qr.VersionNumber),
+		RecoveryLevel: recoveryLevelName(qr.Level),
+		Size:          codeSize(qr, opts),
+	}
+	if err := g.checkPixels(max(opts.Size, totalModules(qr)), opts); err != nil {
+		return nil, Info{}, err
+	}
+	if hasLogo(opts) && !opts.LogoNoResize {
+		info.LogoSize = opts.LogoSize
+	}
+	if g.OnEvent != nil {
+		g.OnEvent(EventEncoded, map[string]any{"elapsed": time.Since(start), "version": info.Version})
+	}
+
+	if opts.Fast {
+		var fast image.Image = qr.Image(opts.Size)
+		// go-qrcode never draws a module smaller than a pixel, so scale down like the full pipeline
+		if opts.ForceExactSize && fast.Bounds().Dx() != opts.Size {
+			fast = imaging.Resize(fast, opts.Size, opts.Size, imaging.Box)
+		}
+		img, err := convertColorModel(fast, opts)
+		if err != nil {
+			return nil, Info{}, err
+		}
+		return img, info, nil
+	}
+
+	quietZone := 0
+	if !qr.DisableBorder {
+		quietZone = quietZoneModules
+	}
+	if opts.CutLine {
+		info.CutLine, err = cutLineSVG(qr.Bitmap(), quietZone, info.Size, opts)
+		if err != nil {
+			return nil, Info{}, err
+		}
+	}
+	img, err := g.renderMatrix(qr.Bitmap(), quietZone, opts, qr.ForegroundColor, qr.BackgroundColor, start)
+	if err != nil {
+		return nil, Info{}, err
+	}
+	return img, info, nil
+}
+
+// renderMatrix draws bitmap (which includes a quiet zone of quietZone modules) and applies the
+// styling options in order: gradient, module styles, logo, caption, letterbox, preview and clip
+func (g *Generator) renderMatrix(bitmap [][]bool, quietZone int, opts Options, fg, bg color.Color, start time.Time) (image.Image, error) {
+	// With a background panel, light modules take the panel color and the background color is
+	// only left outside the panel's rounded corners and around later additions
+	outer := bg
+	if opts.BackgroundPanel {
+		if opts.PanelColor == "" {
+			return nil, fmt.Errorf("background panel requires a panel color")
+		}
+		bg = parseColor(opts.PanelColor)
+	}
+
+	var img image.Image
+	var mask *image.Alpha
+	if opts.ModuleDrawer != nil {
+		mask = drawModuleMask(bitmap, opts.Size, opts.ModuleDrawer)
+		rgba := image.NewRGBA(mask.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+		draw.DrawMask(rgba, rgba.Bounds(), image.NewUniform(fg), image.Point{}, mask, image.Point{}, draw.Over)
+		img = rgba
+	} else {
+		img = drawModules(bitmap, opts.Size, fg, bg)
+	}
+	grid := newModuleGrid(bitmap, quietZone, img.Bounds().Dx())
+	if mask != nil {
+		grid.mask, grid.background = mask, bg
+	}
+
+	if opts.GradientEdgeFade < 0 || opts.GradientEdgeFade > 1 {
+		return nil, fmt.Errorf("gradient edge fade must be between 0 and 1")
+	}
+	if opts.GradientCenterX < 0 || opts.GradientCenterX > 1 || opts.GradientCenterY < 0 || opts.GradientCenterY > 1 {
+		return nil, fmt.Errorf("gradient center must be between 0 and 1")
+	}
+	centerX, centerY := opts.GradientCenterX, opts.GradientCenterY
+	// The zero value of both means unset, not the top left corner
+	if centerX == 0 && centerY == 0 {
+		centerX, centerY = 0.5, 0.5
+	}
+	var gradient *image.RGBA
+	if opts.GradientCorners != [4]string{} {
+		var corners [4]color.Color
+		for i, s := range opts.GradientCorners {
+			c, ok := lookupColor(s)
+			if !ok {
+				return nil, fmt.Errorf("invalid gradient corner color %q", s)
+			}
+			corners[i] = c
+		}
+		var err error
+		gradient, err = bilinearGradient(img.Bounds().Dx(), img.Bounds().Dy(), corners, opts.GradientColorSpace)
+		if err != nil {
+			return nil, err
+		}
+	} else if opts.GradientStart != "" && opts.GradientEnd != "" {
+		mix, err := gradientMix(parseColor(opts.GradientStart), parseColor(opts.GradientEnd), opts.GradientColorSpace)
+		if err != nil {
+			return nil, err
+		}
+		if opts.GradientType == "sequential" {
+			if grid.kinds == nil {
+				return nil, fmt.Errorf("sequential gradient requires a QR symbol matrix")
+			}
+			gradient = grid.sequentialGradient(mix)
+		} else {
+			gradient = createGradient(img.Bounds().Dx(), img.Bounds().Dy(), mix, opts.GradientType, centerX, centerY)
+		}
+	}
+	if gradient != nil {
+		fadeEdges(gradient, opts.GradientEdgeFade)
+		img = applyGradient(img, gradient, mask, fg, bg)
+	}
+
+	if opts.LightModuleColor != "" {
+		rgba := toRGBA(img)
+		grid.recolorLight(rgba, parseColor(opts.LightModuleColor))
+		img = rgba
+	}
+
+	if opts.BackgroundWatermark != "" {
+		rgba := toRGBA(img)
+		if err := drawWatermark(rgba, grid.lightMask(), opts, fg); err != nil {
+			return nil, err
+		}
+		img = rgba
+	}
+
+	switch opts.ModuleShape {
+	case "", "square":
+	case "bevel":
+		if opts.ModuleDrawer != nil {
+			return nil, fmt.Errorf("module shape bevel cannot be combined with a module drawer")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported module shape %q: must be square or bevel", opts.ModuleShape)
+	}
+
+	structured := opts.EyeColor != "" || opts.AlignmentColor != "" || opts.TimingColor != "" || opts.EyeBallShape == "circle"
+	if structured && grid.kinds == nil {
+		return nil, fmt.Errorf("eye color, alignment color, timing color and eye ball shape require a QR symbol matrix")
+	}
+	if structured || opts.ModuleShape == "bevel" {
+		rgba := toRGBA(img)
+		if opts.EyeColor != "" {
+			grid.recolor(rgba, moduleFinder, parseColor(opts.EyeColor))
+		}
+		if opts.AlignmentColor != "" {
+			grid.recolor(rgba, moduleAlignment, parseColor(opts.AlignmentColor))
+		}
+		if opts.TimingColor != "" {
+			grid.recolor(rgba, moduleTiming, parseColor(opts.TimingColor))
+		}
+		if opts.ModuleShape == "bevel" {
+			grid.bevel(rgba)
+		}
+		if opts.EyeBallShape == "circle" {
+			light := bg
+			if opts.LightModuleColor != "" {
+				light = parseColor(opts.LightModuleColor)
+			}
+			grid.roundEyeBalls(rgba, light)
+		}
+		img = rgba
+	}
+
+	if opts.ForceExactSize && img.Bounds().Dx() != opts.Size {
+		img = imaging.Resize(img, opts.Size, opts.Size, imaging.Box)
+		grid.size = opts.Size
+	}
+
+	if opts.BackgroundPanel {
+		radius, err := panelRadius(opts, grid)
+		if err != nil {
+			return nil, err
+		}
+		img = drawPanel(img, radius, outer)
+	}
+
+	if opts.ReserveCenter != 0 {
+		if opts.ReserveCenter < 0 || opts.ReserveCenter > 100 {
+			return nil, fmt.Errorf("reserved center must be between 0 and 100 percent")
+		}
+		side := max(1, int(float64(grid.size)*opts.ReserveCenter/100))
+		offset := (grid.size - side) / 2
+		reserved := grid.snapToModules(image.Rect(offset, offset, offset+side, offset+side))
+		if grid.kinds != nil && grid.overlapsFinders(reserved) {
+			return nil, fmt.Errorf("reserved center of %.1f%% overlaps the finder patterns", opts.ReserveCenter)
+		}
+		rgba := toRGBA(img)
+		draw.Draw(rgba, reserved, image.NewUniform(bg), image.Point{}, draw.Src)
+		img = rgba
+	}
+
+	if hasLogo(opts) {
+		logoImg, err := loadLogo(opts)
+		if err != nil {
+			g.logoFailures.Add(1)
+			return nil, fmt.Errorf("failed to embed logo: %w", err)
+		}
+		withLogo, err := embedLogo(img, logoImg, opts, grid, bg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed logo: %w", err)
+		}
+		img = withLogo
+		if g.OnEvent != nil {
+			g.OnEvent(EventLogoFetched, map[string]any{"elapsed": time.Since(start), "url": opts.LogoURL})
+		}
+	}
+
+	if opts.FrameStyle != "" {
+		l, err := newFrameLayout(opts.FrameStyle, img.Bounds().Dx())
+		if err != nil {
+			return nil, err
+		}
+		face, err := captionFace(opts.CaptionFont, l.label)
+		if err != nil {
+			return nil, err
+		}
+		img, err = drawFrame(img, opts, face, fg, outer)
+		face.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Banner != nil {
+		l, err := newBannerLayout(opts.Banner, img.Bounds().Dy())
+		if err != nil {
+			return nil, err
+		}
+		face, err := captionFace(opts.CaptionFont, l.label)
+		if err != nil {
+			return nil, err
+		}
+		img, err = drawBanner(img, opts.Banner, face, fg, outer)
+		face.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Caption != "" {
+		captionColor := fg
+		if opts.CaptionColor != "" {
+			captionColor = parseColor(opts.CaptionColor)
+		}
+		face, err := captionFace(opts.CaptionFont, opts.CaptionHeight)
+		if err != nil {
+			return nil, err
+		}
+		text := opts.Caption
+		if opts.ShowText {
+			text = ellipsize(text, face, img.Bounds().Dx()-2*captionPadding)
+		}
+		img = drawCaption(img, text, opts.CaptionHeight, face, captionColor, outer)
+		face.Close()
+	}
+
+	if opts.AspectRatio != "" {
+		width, height, err := letterboxSize(img.Bounds().Dx(), img.Bounds().Dy(), opts.AspectRatio)
+		if err != nil {
+			return nil, err
+		}
+		barColor := outer
+		if opts.LetterboxColor != "" {
+			barColor = parseColor(opts.LetterboxColor)
+		}
+		img = letterbox(img, width, height, barColor)
+	}
+
+	if opts.CropMarks {
+		markColor := fg
+		if opts.CropMarkColor != "" {
+			markColor = parseColor(opts.CropMarkColor)
+		}
+		img = withCropMarks(img, markColor, outer)
+	}
+
+	if opts.PreviewCheckerboard {
+		img = overCheckerboard(img)
+	}
+
+	if !opts.Clip.Empty() {
+		var err error
+		img, err = clip(img, opts.Clip)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return convertColorModel(img, opts)
+}
+
+// canvasSize returns the dimensions of the image rendered from a code of size pixels with opts,
+// after frames, banners, captions, letterboxing and crop marks but before clipping. Fast
+// rendering skips all of these
+func canvasSize(size int, opts Options) (int, int, error) {
+	width, height := size, size
+	if opts.Fast {
+		return width, height, nil
+	}
+	if opts.FrameStyle != "" {
+		l, err := newFrameLayout(opts.FrameStyle, size)
+		if err != nil {
+			return 0, 0, err
+		}
+		width, height = l.size(size)
+	}
+	if opts.Banner != nil {
+		l, err := newBannerLayout(opts.Banner, height)
+		if err != nil {
+			return 0, 0, err
+		}
+		width += l.width
+	}
+	if opts.Caption != "" {
+		height += opts.CaptionHeight
+	}
+	if opts.AspectRatio != "" {
+		var err error
+		width, height, err = letterboxSize(width, height, opts.AspectRatio)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	if opts.CropMarks {
+		margin := cropMarkMargin(width, height)
+		width, height = width+2*margin, height+2*margin
+	}
+	return width, height, nil
+}
+
+// defaultMaxPixels is the pixel limit when Generator.MaxPixels is 0, a 4096x4096 canvas
+const defaultMaxPixels = 1 << 24
+
+// checkPixels returns an error when the canvas for a code of size pixels with opts exceeds the
+// generator's pixel limit
+func (g *Generator) checkPixels(size int, opts Options) error {
+	limit := g.MaxPixels
+	if limit == 0 {
+		limit = defaultMaxPixels
+	}
+	if limit < 0 {
+		return nil
+	}
+	width, height, err := canvasSize(size, opts)
+	if err != nil {
+		return err
+	}
+	if int64(width)*int64(height) > int64(limit) {
+		return fmt.Errorf("image of %dx%d pixels exceeds the limit of %d pixels", width, height, limit)
+	}
+	return nil
+}
+
+// GenerateImage generates a QR code as an image.Image, applying all styling options
+func (g *Generator) GenerateImage(opts Options) (image.Image, error) {
+	img, _, err := g.render(opts, time.Now())
+	return img, err
+}
+
+// RenderOptions configures RenderMatrix. The embedded Options supply size, colors and styling;
+// encoding options (Data, Error, Border, MinVersion, MaxVersion) are ignored
+type RenderOptions struct {
+	Options
+
+	// QuietZone is the width in modules of the light border drawn around the matrix
+	QuietZone int
+}
+
+// RenderMatrix draws a precomputed module matrix, indexed as matrix[y][x] with true for dark
+// modules and without quiet zone, applying the same styling as GeneratePNG. This allows
+// rendering matrices from other encoders. Options that depend on the QR structure
+// (EyeColor, AlignmentColor, TimingColor, EyeBallShape) require a QR symbol matrix of 21x21 to 177x177 modules
+func (g *Generator) RenderMatrix(matrix [][]bool, opts RenderOptions) (image.Image, error) {
+	if len(matrix) == 0 {
+		return nil, fmt.Errorf("matrix is required")
+	}
+	for y, row := range matrix {
+		if len(row) != len(matrix) {
+			return nil, fmt.Errorf("matrix must be square: row %d has %d modules, want %d", y, len(row), len(matrix))
+		}
+	}
+	quietZone := max(opts.QuietZone, 0)
+
+	bitmap := make([][]bool, len(matrix)+2*quietZone)
+	for y := range bitmap {
+		bitmap[y] = make([]bool, len(bitmap))
+		if y >= quietZone && y < quietZone+len(matrix) {
+			copy(bitmap[y][quietZone:], matrix[y-quietZone])
+		}
+	}
+
+	styling := g.withDefaults(opts.Options)
+	if styling.LogoAuto {
+		return nil, fmt.Errorf("logo auto sizing requires an error correction level and is not supported for matrices")
+	}
+	applyDefaults(&styling)
+	if err := checkEyeBallShape(styling.EyeBallShape); err != nil {
+		return nil, err
+	}
+	if err := g.checkPixels(max(styling.Size, len(bitmap)), styling); err != nil {
+		return nil, err
+	}
+	fg, bg := moduleColors(styling)
+	return g.renderMatrix(bitmap, quietZone, styling, fg, bg, time.Now())
+}
+
+// GeneratePNGReader is a convenience function that creates a generator and returns a reader
+// over a generated PNG
+func GeneratePNGReader(opts Options) (io.ReadCloser, int, error) {
+	g := New()
+	return g.GeneratePNGReader(opts)
+}
+
+// GeneratePNGTo is a convenience function that creates a generator and writes a generated PNG to w
+func GeneratePNGTo(w io.Writer, opts Options) error {
+	g := New()
+	return g.GeneratePNGTo(w, opts)
+}
+
+// GenerateImage is a convenience function that creates a generator and generates a QR code image
+func GenerateImage(opts Options) (image.Image, error) {
+	g := New()
+	return g.GenerateImage(opts)
+}
+
+// RenderMatrix is a convenience function that creates a generator and renders a module matrix
+func RenderMatrix(matrix [][]bool, opts RenderOptions) (image.Image, error) {
+	g := New()
+	return g.RenderMatrix(matrix, opts)
+}
+
+// GenerateWithInfo is a convenience function that creates a generator and generates a QR code
+// along with information about the encoded symbol
+func GenerateWithInfo(opts Options) ([]byte, Info, error) {
+	g := New()
+	return g.GenerateWithInfo(opts)
+}
+
+// GenerateFrames is a convenience function that creates a generator and renders animation frames
+func GenerateFrames(opts Options, frameCount int, animate func(i int, o *Options)) ([]image.Image, error) {
+	g := New()
+	return g.GenerateFrames(opts, frameCount, animate)
+}
+
+// OutputBounds returns the pixel dimensions GeneratePNG would produce for opts without rendering
+func (g *Generator) OutputBounds(opts Options) (image.Rectangle, error) {
+	opts = g.withDefaults(opts)
+	qr, err := g.prepare(&opts)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+
+	width, height, err := canvasSize(codeSize(qr, opts), opts)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	bounds := image.Rect(0, 0, width, height)
+	if !opts.Clip.Empty() && !opts.Fast {
+		clipped := opts.Clip.Intersect(bounds)
+		if clipped.Empty() {
+			return image.Rectangle{}, fmt.Errorf("clip rectangle %v lies outside image bounds %v", opts.Clip, bounds)
+		}
+		bounds = image.Rect(0, 0, clipped.Dx(), clipped.Dy())
+	}
+	return bounds, nil
+}
+
+// GenerateFrames renders frameCount images, calling animate (if non-nil) with frame index i and
+// a copy of opts to adjust before each frame is rendered. The frames can be assembled into
+// GIF, APNG or video by the caller
+func (g *Generator) GenerateFrames(opts Options, frameCount int, animate func(i int, o *Options)) ([]image.Image, error) {
+	if frameCount <= 0 {
+		return nil, fmt.Errorf("frame count must be positive")
+	}
+
+	frames := make([]image.Image, frameCount)
+	for i := range frames {
+		frameOpts := opts
+		if animate != nil {
+			animate(i, &frameOpts)
+		}
+		img, _, err := g.render(frameOpts, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("failed to render frame %d: %w", i, err)
+		}
+		frames[i] = img
+	}
+	return frames, nil
+}
+
+// GeneratePNG is a convenience function that creates a generator and generates a QR code
+func GeneratePNG(opts Options) ([]byte, error) {
+	g := New()
+	return g.GeneratePNG(opts)
+}
+
+// prepare shortens opts.Data with the generator's Shortener, if any, and then prepares the code
+// like the package-level prepare
+func (g *Generator) prepare(opts *Options) (*qrcode.QRCode, error) {
+	if g.Defaults.LogoReader != nil {
+		return nil, fmt.Errorf("generator defaults cannot set a logo reader, which is consumed by the first generation: set LogoPath or LogoURL, or pass LogoReader per call")
+	}
+	if err := g.shorten(opts); err != nil {
+		return nil, err
+	}
+	return prepare(opts)
+}
+
+// shorten rewrites opts.Data with the generator's Shortener unless it has already been
+// shortened. Entry points that encode opts several times call it first, so copies of opts
+// carry the shortened data
+func (g *Generator) shorten(opts *Options) error {
+	if g.Shortener == nil || opts.Data == "" || opts.shortened {
+		return nil
+	}
+	short, err := g.Shortener(opts.Data)
+	if err != nil {
+		return fmt.Errorf("failed to shorten data: %w", err)
+	}
+	opts.Data = short
+	opts.shortened = true
+	return nil
+}
+
+// prepare validates opts, applies defaults and initializes the underlying QR code
+func prepare(opts *Options) (*qrcode.QRCode, error) {
+	if opts.Data == "" {
+		return nil, fmt.Errorf("data is required")
+	}
+	if opts.ShowText && opts.Caption == "" {
+		opts.Caption = opts.Data
+	}
+	if opts.PayloadWrapper != nil {
+		data, err := opts.PayloadWrapper(opts.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wrap payload: %w", err)
+		}
+		if data == "" {
+			return nil, fmt.Errorf("payload wrapper returned empty data")
+		}
+		opts.Data = data
+	}
+	if opts.UTF8BOM {
+		opts.Data = utf8BOM + opts.Data
+	}
 
-	// Error is the error correction level: L (Low ~7%), M (Medium ~15%), Q (High ~25%), H (Highest ~30%)
-	// Default: M
-	Error string
+	if err := resolvePhysicalSize(opts); err != nil {
+		return nil, err
+	}
+	if opts.ErrorLevel != 0 {
+		if _, ok := errorLevelNames[opts.ErrorLevel]; !ok {
+			return nil, fmt.Errorf("invalid error level %d", int(opts.ErrorLevel))
+		}
+		opts.Error = opts.ErrorLevel.String()
+	}
+	applyDefaults(opts)
 
-	// Border is the border width in pixels (0 = no border)
-	// Default: 0
-	Border int
+	if err := checkEyeBallShape(opts.EyeBallShape); err != nil {
+		return nil, err
+	}
+	if opts.MinVersion < 0 || opts.MinVersion > 40 || opts.MaxVersion < 0 || opts.MaxVersion > 40 {
+		return nil, fmt.Errorf("version bounds must be between 1 and 40")
+	}
+	if opts.MaxVersion > 0 && opts.MinVersion > opts.MaxVersion {
+		return nil, fmt.Errorf("min version %d exceeds max version %d", opts.MinVersion, opts.MaxVersion)
+	}
 
-	// LogoURL is the URL to a logo image to embed in the center of the QR code
-	LogoURL string
+	level := getErrorCorrection(opts.Error)
+	// No content fits once it exceeds the numeric capacity; mixed content below that depends
+	// on how go-qrcode segments it, so only its failure is conclusive
+	if len(opts.Data) > version40Capacity[level][0] {
+		return nil, errDataTooLong(opts.Data, level, opts.Error)
+	}
+	qr, err := qrcode.New(opts.Data, level)
+	if err != nil {
+		if capacity, _ := dataCapacity(opts.Data, level); len(opts.Data) > capacity {
+			return nil, errDataTooLong(opts.Data, level, opts.Error)
+		}
+		return nil, fmt.Errorf("failed to init qrcode: %w", err)
+	}
+	if opts.MaxVersion > 0 && qr.VersionNumber > opts.MaxVersion {
+		return nil, fmt.Errorf("data requires version %d, exceeding max version %d at error level %s", qr.VersionNumber, opts.MaxVersion, opts.Error)
+	}
+	if qr.VersionNumber < opts.MinVersion {
+		qr, err = qrcode.NewWithForcedVersion(opts.Data, opts.MinVersion, getErrorCorrection(opts.Error))
+		if err != nil {
+			return nil, fmt.Errorf("failed to init qrcode: %w", err)
+		}
+	}
 
-	// LogoSize is the logo size as a percentage of the QR code (default: 20.0)
-	LogoSize float64
+	qr.ForegroundColor, qr.BackgroundColor = moduleColors(*opts)
 
-	// GradientStart is the start color for gradient effect
-	// Requires GradientEnd to be set
-	GradientStart string
+	qr.DisableBorder = opts.Border == 0
+	opts.Size += borderPadding(*opts)
 
-	// GradientEnd is the end color for gradient effect
-	// Requires GradientStart to be set
-	GradientEnd string
+	if opts.SnapToModule {
+		if opts.ForceExactSize {
+			return nil, fmt.Errorf("snap to module cannot be combined with force exact size")
+		}
+		n := totalModules(qr)
+		opts.Size = (opts.Size + n - 1) / n * n
+	}
+	return qr, nil
+}
 
-	// GradientType is the type of gradient: "linear" or "radial" (default: "linear")
-	GradientType string
+// checkEyeBallShape returns an error unless shape is a supported EyeBallShape
+func checkEyeBallShape(shape string) error {
+	switch shape {
+	case "", "square", "circle":
+		return nil
+	default:
+		return fmt.Errorf("unsupported eye ball shape %q: must be square or circle", shape)
+	}
 }
 
-// Generator provides QR code generation functionality
-type Generator struct{}
+// borderPadding returns the pixels a Border wider than the quiet zone adds to Size
+func borderPadding(opts Options) int {
+	if opts.ForceExactSize {
+		return 0
+	}
+	return 2 * max(0, opts.Border-quietZoneModules)
+}
 
-// New creates a new QR code generator
-func New() *Generator {
-	return &Generator{}
+// totalModules returns the number of modules per side of qr, including the quiet zone
+func totalModules(qr *qrcode.QRCode) int {
+	n := symbolSize(qr.VersionNumber)
+	if !qr.DisableBorder {
+		n += 2 * quietZoneModules
+	}
+	return n
 }
 
-// GeneratePNG generates a QR code as a PNG image byte array
-func (g *Generator) GeneratePNG(opts Options) ([]byte, error) {
-	if opts.Data == "" {
-		return nil, fmt.Errorf("data is required")
+// codeSize returns the side in pixels of the code prepared from opts, before captions and
+// other canvas changes
+func codeSize(qr *qrcode.QRCode, opts Options) int {
+	if opts.ForceExactSize {
+		return opts.Size
 	}
+	return max(opts.Size, totalModules(qr))
+}
+
+// utf8BOM is the UTF-8 encoded byte order mark
+const utf8BOM = "\ufeff"
 
+// applyDefaults fills in defaults for unset or out-of-range options
+func applyDefaults(opts *Options) {
 	if opts.Size <= 0 {
 		opts.Size = 300
 	}
@@ -86,63 +1285,210 @@ func (g *Generator) GeneratePNG(opts Options) ([]byte, error) {
 	if opts.LogoSize <= 0 {
 		opts.LogoSize = 20.0
 	}
+	if opts.CaptionHeight <= 0 {
+		opts.CaptionHeight = defaultCaptionHeight
+	}
+	if opts.DarkMode {
+		applyDarkMode(opts)
+	}
+}
 
-	qr, err := qrcode.New(opts.Data, getErrorCorrection(opts.Error))
-	if err != nil {
-		return nil, fmt.Errorf("failed to init qrcode: %w", err)
+// Dark mode default colors
+const (
+	darkModeForeground = "rgb(230,230,230)"
+	darkModeBackground = "rgb(18,18,18)"
+)
+
+// minDarkModeContrast is the WCAG AA contrast ratio a dark mode default must reach against the
+// explicitly set color
+const minDarkModeContrast = 4.5
+
+// applyDarkMode fills the empty colors of opts with dark mode defaults
+func applyDarkMode(opts *Options) {
+	switch {
+	case opts.Foreground == "" && opts.Background == "":
+		opts.Foreground, opts.Background = darkModeForeground, darkModeBackground
+	case opts.Foreground == "":
+		opts.Foreground = contrastingColor(opts.Background, darkModeForeground)
+	case opts.Background == "":
+		opts.Background = contrastingColor(opts.Foreground, darkModeBackground)
 	}
+}
+
+// contrastingColor returns preferred if it contrasts enough with other, and otherwise whichever
+// of black and white contrasts more
+func contrastingColor(other, preferred string) string {
+	c := parseColor(other)
+	if contrastRatio(c, parseColor(preferred)) >= minDarkModeContrast {
+		return preferred
+	}
+	if contrastRatio(c, color.White) > contrastRatio(c, color.Black) {
+		return "white"
+	}
+	return "black"
+}
 
-	qr.ForegroundColor = parseColor(opts.Foreground)
-	qr.BackgroundColor = parseColor(opts.Background)
+// contrastRatio returns the WCAG contrast ratio (1-21) of two colors, ignoring alpha
+func contrastRatio(a, b color.Color) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	return (math.Max(la, lb) + 0.05) / (math.Min(la, lb) + 0.05)
+}
 
-	if opts.Border == 0 {
-		qr.DisableBorder = true
-	} else {
-		qr.DisableBorder = false
-		extra := opts.Border - 4
-		if extra > 0 {
-			opts.Size += extra * 2
+// relativeLuminance returns the WCAG relative luminance of c
+func relativeLuminance(c color.Color) float64 {
+	r, g, b, _ := c.RGBA()
+	return 0.2126*srgbToLinear(float64(r)/0xffff) + 0.7152*srgbToLinear(float64(g)/0xffff) + 0.0722*srgbToLinear(float64(b)/0xffff)
+}
+
+// moduleColors returns the colors for dark and light modules, honoring Invert. An unset or
+// invalid Foreground falls back to black and Background to white, so a bad color never leaves
+// both the same
+func moduleColors(opts Options) (fg, bg color.Color) {
+	fg = parseColorOr(opts.Foreground, color.Black)
+	bg = parseColorOr(opts.Background, color.White)
+	if opts.Invert {
+		fg, bg = bg, fg
+	}
+	return fg, bg
+}
+
+// drawModules draws bitmap onto a paletted image of at least size x size pixels, mapping each
+// pixel to its nearest module the same way go-qrcode does
+func drawModules(bitmap [][]bool, size int, fg, bg color.Color) *image.Paletted {
+	n := len(bitmap)
+	size = max(size, n)
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{bg, fg})
+	fgIndex := uint8(img.Palette.Index(fg))
+	modulesPerPixel := float64(n) / float64(size)
+	for y := 0; y < size; y++ {
+		row := bitmap[int(float64(y)*modulesPerPixel)]
+		for x := 0; x < size; x++ {
+			if row[int(float64(x)*modulesPerPixel)] {
+				img.Pix[img.PixOffset(x, y)] = fgIndex
+			}
 		}
 	}
+	return img
+}
+
+// clip returns the part of img inside r, translated to the origin
+func clip(img image.Image, r image.Rectangle) (*image.RGBA, error) {
+	r = r.Intersect(img.Bounds())
+	if r.Empty() {
+		return nil, fmt.Errorf("clip rectangle lies outside image bounds %v", img.Bounds())
+	}
+	clipped := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(clipped, clipped.Bounds(), img, r.Min, draw.Src)
+	return clipped, nil
+}
 
-	var buf bytes.Buffer
-	if err := qr.Write(opts.Size, &buf); err != nil {
-		return nil, fmt.Errorf("failed to render qrcode: %w", err)
+// toRGBA returns img as an *image.RGBA, converting it if necessary
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
 	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba
+}
 
-	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode qrcode: %w", err)
-	}
-
-	if opts.GradientStart != "" && opts.GradientEnd != "" {
-		start := parseColor(opts.GradientStart)
-		end := parseColor(opts.GradientEnd)
-		gradient := createGradient(img.Bounds().Dx(), img.Bounds().Dy(), start, end, opts.GradientType)
-		finalImg := image.NewRGBA(img.Bounds())
-		draw.Draw(finalImg, finalImg.Bounds(), gradient, image.Point{}, draw.Src)
-		for y := 0; y < img.Bounds().Dy(); y++ {
-			for x := 0; x < img.Bounds().Dx(); x++ {
-				r, g, b, _ := img.At(x, y).RGBA()
-				fr, fg, fb, _ := qr.ForegroundColor.RGBA()
-				if r == fr && g == fg && b == fb {
-					finalImg.Set(x, y, gradient.At(x, y))
-				} else {
-					finalImg.Set(x, y, qr.BackgroundColor)
-				}
+// convertColorModel returns img converted to the image type named by opts.ColorModel, or img
+// itself when it is empty or img already has that type
+func convertColorModel(img image.Image, opts Options) (image.Image, error) {
+	bounds := img.Bounds()
+	var dst draw.Image
+	switch opts.ColorModel {
+	case "":
+		return img, nil
+	case "rgba":
+		return toRGBA(img), nil
+	case "nrgba":
+		if _, ok := img.(*image.NRGBA); ok {
+			return img, nil
+		}
+		dst = image.NewNRGBA(bounds)
+	case "gray":
+		if _, ok := img.(*image.Gray); ok {
+			return img, nil
+		}
+		dst = image.NewGray(bounds)
+	case "paletted":
+		if _, ok := img.(*image.Paletted); ok {
+			return img, nil
+		}
+		dst = image.NewPaletted(bounds, imagePalette(img))
+	case "monochrome":
+		threshold := opts.MonochromeThreshold
+		if threshold == 0 {
+			threshold = defaultMonochromeThreshold
+		}
+		return monochrome(img, threshold), nil
+	default:
+		return nil, fmt.Errorf("unsupported color model %q: must be rgba, nrgba, gray, paletted or monochrome", opts.ColorModel)
+	}
+	draw.Draw(dst, bounds, img, bounds.Min, draw.Src)
+	return dst, nil
+}
+
+// defaultMonochromeThreshold is the monochrome luminance threshold when MonochromeThreshold is unset
+const defaultMonochromeThreshold = 128
+
+// monochrome converts img to a black and white image, turning pixels whose luminance is below
+// threshold black. Translucent pixels are composited over white first
+func monochrome(img image.Image, threshold uint8) *image.Paletted {
+	bounds := img.Bounds()
+	dst := image.NewPaletted(bounds, color.Palette{color.White, color.Black})
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			// Premultiplied components over white add the uncovered share of white
+			r, g, b, a := img.At(x, y).RGBA()
+			white := 0xffff - a
+			lum := (299*(r+white) + 587*(g+white) + 114*(b+white)) / 1000 >> 8
+			if lum < uint32(threshold) {
+				dst.SetColorIndex(x, y, 1)
 			}
 		}
-		img = finalImg
 	}
+	return dst
+}
 
-	if opts.LogoURL != "" {
-		withLogo, err := embedLogo(img, opts.LogoURL, opts.LogoSize)
-		if err != nil {
-			return nil, fmt.Errorf("failed to embed logo: %w", err)
+// imagePalette returns the distinct colors of img, or the Plan 9 palette if there are more
+// than 256
+func imagePalette(img image.Image) color.Palette {
+	bounds := img.Bounds()
+	seen := make(map[color.RGBA64]bool)
+	var colors color.Palette
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.RGBA64Model.Convert(img.At(x, y)).(color.RGBA64)
+			if seen[c] {
+				continue
+			}
+			if len(colors) == 256 {
+				return palette.Plan9
+			}
+			seen[c] = true
+			colors = append(colors, c)
 		}
-		img = withLogo
 	}
+	return colors
+}
+
+// imageFingerprint returns the hex SHA-256 of the dimensions and NRGBA pixels of img
+func imageFingerprint(img image.Image) string {
+	bounds := img.Bounds()
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok || nrgba.Rect.Min != (image.Point{}) || nrgba.Stride != 4*bounds.Dx() {
+		nrgba = image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+	}
+	h := sha256.New()
+	binary.Write(h, binary.BigEndian, [2]uint32{uint32(bounds.Dx()), uint32(bounds.Dy())})
+	h.Write(nrgba.Pix)
+	return hex.EncodeToString(h.Sum(nil))
+}
 
+func encodePNG(img image.Image) ([]byte, error) {
 	var out bytes.Buffer
 	if err := png.Encode(&out, img); err != nil {
 		return nil, fmt.Errorf("failed to encode png: %w", err)
@@ -150,34 +1496,146 @@ func (g *Generator) GeneratePNG(opts Options) ([]byte, error) {
 	return out.Bytes(), nil
 }
 
-// GeneratePNG is a convenience function that creates a generator and generates a QR code
-func GeneratePNG(opts Options) ([]byte, error) {
-	g := New()
-	return g.GeneratePNG(opts)
+// parseColor parses colorStr, falling back to black when it is not a supported color
+func parseColor(colorStr string) color.Color {
+	return parseColorOr(colorStr, color.Black)
 }
 
-func parseColor(colorStr string) color.Color {
+// parseColorOr parses colorStr, falling back to fallback when it is not a supported color
+func parseColorOr(colorStr string, fallback color.Color) color.Color {
+	if c, ok := lookupColor(colorStr); ok {
+		return c
+	}
+	return fallback
+}
+
+// lookupColor parses colorStr and reports whether it is a supported color format
+func lookupColor(colorStr string) (color.Color, bool) {
 	var r, g, b, a uint8 = 0, 0, 0, 255
 	if n, err := fmt.Sscanf(colorStr, "rgb(%d,%d,%d)", &r, &g, &b); err == nil && n == 3 {
-		return color.RGBA{R: r, G: g, B: b, A: a}
+		return color.RGBA{R: r, G: g, B: b, A: a}, true
 	}
+	// CSS rgba values are not premultiplied, unlike color.RGBA
 	if n, err := fmt.Sscanf(colorStr, "rgba(%d,%d,%d,%d)", &r, &g, &b, &a); err == nil && n == 4 {
-		return color.RGBA{R: r, G: g, B: b, A: a}
+		return color.NRGBA{R: r, G: g, B: b, A: a}, true
 	}
 	switch strings.ToLower(colorStr) {
 	case "black":
-		return color.Black
+		return color.Black, true
 	case "white":
-		return color.White
+		return color.White, true
 	case "red":
-		return color.RGBA{R: 255, A: 255}
+		return color.RGBA{R: 255, A: 255}, true
 	case "green":
-		return color.RGBA{G: 255, A: 255}
+		return color.RGBA{G: 255, A: 255}, true
 	case "blue":
-		return color.RGBA{B: 255, A: 255}
+		return color.RGBA{B: 255, A: 255}, true
+	case "transparent":
+		return color.Transparent, true
+	default:
+		return parseHexColor(colorStr)
+	}
+}
+
+// parseHexColor parses #RGB, #RRGGBB and #RRGGBBAA colors, case-insensitively and with an
+// optional leading "#". In #RGB each digit is doubled, so #f00 is #ff0000
+func parseHexColor(colorStr string) (color.Color, bool) {
+	hex := strings.TrimPrefix(colorStr, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return nil, false
+	}
+	var rgba [4]uint8
+	for i := range rgba {
+		v, err := strconv.ParseUint(hex[2*i:2*i+2], 16, 8)
+		if err != nil {
+			return nil, false
+		}
+		rgba[i] = uint8(v)
+	}
+	return color.NRGBA{R: rgba[0], G: rgba[1], B: rgba[2], A: rgba[3]}, true
+}
+
+// ErrDataTooLong is returned when the data does not fit into a version 40 symbol, the largest
+// QR code, at the requested error correction level
+var ErrDataTooLong = errors.New("data too long to encode")
+
+// version40Capacity is the number of characters a version 40 symbol holds per error correction
+// level in numeric, alphanumeric and byte mode
+var version40Capacity = map[qrcode.RecoveryLevel][3]int{
+	qrcode.Low:     {7089, 4296, 2953},
+	qrcode.Medium:  {5596, 3391, 2331},
+	qrcode.High:    {3993, 2420, 1663},
+	qrcode.Highest: {3057, 1852, 1273},
+}
+
+// dataCapacity returns the version 40 capacity for data at level in the most compact
+// encoding mode that covers all of data, and the name of that mode
+func dataCapacity(data string, level qrcode.RecoveryLevel) (int, string) {
+	numeric, alphanumeric := true, true
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if c < '0' || c > '9' {
+			numeric = false
+		}
+		if (c < '0' || c > '9') && (c < 'A' || c > 'Z') && !strings.ContainsRune(" $%*+-./:", rune(c)) {
+			alphanumeric = false
+		}
+	}
+	switch {
+	case numeric:
+		return version40Capacity[level][0], "numeric"
+	case alphanumeric:
+		return version40Capacity[level][1], "alphanumeric"
 	default:
-		return color.Black
+		return version40Capacity[level][2], "byte"
+	}
+}
+
+// errDataTooLong wraps ErrDataTooLong with the length and capacity of data
+func errDataTooLong(data string, level qrcode.RecoveryLevel, errorLevel string) error {
+	capacity, mode := dataCapacity(data, level)
+	return fmt.Errorf("%w: %d characters exceed the %s mode capacity of %d at error level %s",
+		ErrDataTooLong, len(data), mode, capacity, errorLevel)
+}
+
+// ModuleCountFor returns the range of modules per side (excluding the quiet zone) a code for
+// dataLen bytes of data takes at errorLevel ("L", "M", "Q" or "H"; default "M"), without
+// rendering, e.g. for a live detail indicator. min is for all-numeric data, the most compact
+// mode, and max for binary data; max is 177, version 40, when only more compact modes fit
+func ModuleCountFor(dataLen int, errorLevel string) (min, max int, err error) {
+	if dataLen <= 0 {
+		return 0, 0, fmt.Errorf("data length must be positive")
 	}
+	if errorLevel == "" {
+		errorLevel = "M"
+	}
+	if !strings.Contains("LMQH", errorLevel) || len(errorLevel) != 1 {
+		return 0, 0, fmt.Errorf("invalid error level %q: must be L, M, Q or H", errorLevel)
+	}
+	level := getErrorCorrection(errorLevel)
+	// Check the bound before building the probes, whose size is dataLen
+	if capacity := version40Capacity[level][0]; dataLen > capacity {
+		return 0, 0, fmt.Errorf("%w: %d characters exceed the numeric mode capacity of %d at error level %s",
+			ErrDataTooLong, dataLen, capacity, errorLevel)
+	}
+
+	numeric := strings.Repeat("0", dataLen)
+	qr, err := qrcode.New(numeric, level)
+	if err != nil {
+		return 0, 0, errDataTooLong(numeric, level, errorLevel)
+	}
+	min = symbolSize(qr.VersionNumber)
+	max = symbolSize(40)
+	if qr, err := qrcode.New(strings.Repeat("a", dataLen), level); err == nil {
+		max = symbolSize(qr.VersionNumber)
+	}
+	return min, max, nil
 }
 
 func getErrorCorrection(level string) qrcode.RecoveryLevel {
@@ -195,55 +1653,423 @@ func getErrorCorrection(level string) qrcode.RecoveryLevel {
 	}
 }
 
-func embedLogo(qrImage image.Image, logoURL string, sizePercent float64) (image.Image, error) {
-	resp, err := http.Get(logoURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch logo: %w", err)
+// recoveryLevelName returns the Options.Error letter for level
+func recoveryLevelName(level qrcode.RecoveryLevel) string {
+	switch level {
+	case qrcode.Low:
+		return "L"
+	case qrcode.High:
+		return "Q"
+	case qrcode.Highest:
+		return "H"
+	default:
+		return "M"
+	}
+}
+
+// logoDecoders are the decoders selectable with Options.LogoFormat
+var logoDecoders = map[string]func(io.Reader) (image.Image, error){
+	"png":  png.Decode,
+	"jpeg": jpeg.Decode,
+	"jpg":  jpeg.Decode,
+	"gif":  gif.Decode,
+	"bmp":  bmp.Decode,
+	"tiff": tiff.Decode,
+	"webp": webp.Decode,
+}
+
+// recoveryCapacity is the share of codewords each error correction level can restore
+var recoveryCapacity = map[qrcode.RecoveryLevel]float64{
+	qrcode.Low:     0.07,
+	qrcode.Medium:  0.15,
+	qrcode.High:    0.25,
+	qrcode.Highest: 0.30,
+}
+
+// logoAutoBudget is the share of the recovery capacity a LogoAuto logo may use; the rest absorbs
+// codewords the logo only partly covers and ordinary scanning damage
+const logoAutoBudget = 0.5
+
+// autoLogoSize returns the largest centered logo, as a percentage of the code size, whose area
+// stays within logoAutoBudget of the recovery capacity of qr and which clears the finder
+// patterns and their separators
+func autoLogoSize(qr *qrcode.QRCode) float64 {
+	n := float64(symbolSize(qr.VersionNumber))
+	side := n * math.Sqrt(recoveryCapacity[qr.Level]*logoAutoBudget)
+	side = math.Max(0, math.Min(side, n-16))
+	return side / float64(totalModules(qr)) * 100
+}
+
+// hasLogo reports whether opts sets any logo source
+func hasLogo(opts Options) bool {
+	return opts.LogoReader != nil || opts.LogoPath != "" || opts.LogoURL != ""
+}
+
+// loadLogo reads the logo from the first source set in opts (LogoReader, LogoPath, then
+// LogoURL) and decodes it with LogoFormat's decoder, or detects its format
+func loadLogo(opts Options) (image.Image, error) {
+	decode := func(r io.Reader) (image.Image, error) { return imaging.Decode(r) }
+	if opts.LogoFormat != "" {
+		var ok bool
+		if decode, ok = logoDecoders[strings.ToLower(opts.LogoFormat)]; !ok {
+			return nil, fmt.Errorf("unsupported logo format %q", opts.LogoFormat)
+		}
+	}
+
+	var r io.Reader
+	switch {
+	case opts.LogoReader != nil:
+		r = opts.LogoReader
+	case opts.LogoPath != "":
+		f, err := os.Open(opts.LogoPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open logo: %w", err)
+		}
+		defer f.Close()
+		r = f
+	default:
+		resp, err := fetchLogo(opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch logo: %w", err)
+		}
+		defer resp.Body.Close()
+		r = resp.Body
 	}
-	defer resp.Body.Close()
 
-	logoImg, err := imaging.Decode(resp.Body)
+	logoImg, err := decode(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode logo image: %w", err)
 	}
+	return logoImg, nil
+}
 
+// embedLogo composites logoImg onto qrImage, first clearing the modules under it when
+// opts.LogoCutout is set
+func embedLogo(qrImage, logoImg image.Image, opts Options, grid *moduleGrid, bg color.Color) (image.Image, error) {
 	qrSize := qrImage.Bounds().Size()
-	logoWidth := int(float64(qrSize.X) * sizePercent / 100)
-	logoHeight := int(float64(qrSize.Y) * sizePercent / 100)
-	logoImg = imaging.Fit(logoImg, logoWidth, logoHeight, imaging.Lanczos)
+	var logoWidth, logoHeight int
+	resize := !opts.LogoNoResize
+	if opts.LogoNoResize {
+		logoWidth, logoHeight = logoImg.Bounds().Dx(), logoImg.Bounds().Dy()
+		if logoWidth > qrSize.X || logoHeight > qrSize.Y {
+			return nil, fmt.Errorf("logo size %dx%d exceeds qrcode size %dx%d", logoWidth, logoHeight, qrSize.X, qrSize.Y)
+		}
+	} else {
+		logoWidth = int(float64(qrSize.X) * opts.LogoSize / 100)
+		logoHeight = int(float64(qrSize.Y) * opts.LogoSize / 100)
+	}
+	place := func(width, height int) image.Rectangle {
+		x := (qrSize.X-width)/2 + opts.LogoOffsetX
+		y := (qrSize.Y-height)/2 + opts.LogoOffsetY
+		return image.Rect(x, y, x+width, y+height)
+	}
+	logoPos := place(logoWidth, logoHeight)
+	if !logoPos.In(qrImage.Bounds()) {
+		return nil, fmt.Errorf("logo at offset (%d,%d) exceeds qrcode bounds", opts.LogoOffsetX, opts.LogoOffsetY)
+	}
+
+	if grid.kinds != nil && grid.overlapsFinders(logoPos) {
+		if opts.LogoStrict {
+			return nil, fmt.Errorf("logo at %v overlaps the finder patterns of this version %d code", logoPos, (len(grid.kinds)-17)/4)
+		}
+		// Shrink the logo box, keeping its aspect ratio, until it clears the finders
+		width, height := logoWidth, logoHeight
+		for grid.overlapsFinders(logoPos) && width > 1 && height > 1 {
+			width--
+			height = max(1, logoHeight*width/logoWidth)
+			logoPos = place(width, height)
+		}
+		logoWidth, logoHeight = width, height
+		resize = true
+	}
+	if resize {
+		if opts.LogoStretch {
+			logoImg = imaging.Resize(logoImg, logoWidth, logoHeight, imaging.Lanczos)
+		} else {
+			logoImg = imaging.Fit(logoImg, logoWidth, logoHeight, imaging.Lanczos)
+		}
+	}
+	if opts.LogoTint != "" {
+		logoImg = tintLogo(logoImg, parseColor(opts.LogoTint))
+	}
+
+	// Layers compose bottom up with draw.Over: the code (background, modules and gradient), the
+	// cutout, which first clears its modules back to the background, then the logo
 	finalImg := image.NewRGBA(qrImage.Bounds())
-	draw.Draw(finalImg, finalImg.Bounds(), qrImage, image.Point{}, draw.Over)
-	x := (qrSize.X - logoWidth) / 2
-	y := (qrSize.Y - logoHeight) / 2
-	logoPos := image.Rect(x, y, x+logoWidth, y+logoHeight)
-	draw.Draw(finalImg, logoPos, logoImg, image.Point{}, draw.Over)
+	draw.Draw(finalImg, finalImg.Bounds(), qrImage, qrImage.Bounds().Min, draw.Src)
+	if opts.LogoCutout {
+		cutout := grid.snapToModules(logoPos)
+		draw.Draw(finalImg, cutout, image.NewUniform(bg), image.Point{}, draw.Src)
+		if opts.LogoBackground != "" {
+			draw.Draw(finalImg, cutout, image.NewUniform(parseColor(opts.LogoBackground)), image.Point{}, draw.Over)
+		}
+	}
+	draw.Draw(finalImg, logoPos, logoImg, logoImg.Bounds().Min, draw.Over)
 	return finalImg, nil
 }
 
-func createGradient(width, height int, startColor, endColor color.Color, gradientType string) *image.RGBA {
+// tintLogo maps every pixel of logo to tint blended towards white by the pixel's luminance,
+// keeping its alpha
+func tintLogo(logo image.Image, tint color.Color) *image.NRGBA {
+	out := imaging.Clone(logo)
+	t := color.NRGBAModel.Convert(tint).(color.NRGBA)
+	for i := 0; i < len(out.Pix); i += 4 {
+		p := out.Pix[i : i+4 : i+4]
+		lum := (0.299*float64(p[0]) + 0.587*float64(p[1]) + 0.114*float64(p[2])) / 255
+		for c, v := range []uint8{t.R, t.G, t.B} {
+			p[c] = uint8(math.Round(float64(v) + (255-float64(v))*lum))
+		}
+	}
+	return out
+}
+
+// letterboxSize returns the smallest dimensions with the given "W:H" aspect ratio that contain
+// a width x height image
+func letterboxSize(width, height int, ratio string) (int, int, error) {
+	var rw, rh int
+	if n, err := fmt.Sscanf(ratio, "%d:%d", &rw, &rh); err != nil || n != 2 || rw <= 0 || rh <= 0 {
+		return 0, 0, fmt.Errorf("invalid aspect ratio %q: expected W:H", ratio)
+	}
+	if width*rh >= height*rw {
+		return width, int(math.Round(float64(width) * float64(rh) / float64(rw))), nil
+	}
+	return int(math.Round(float64(height) * float64(rw) / float64(rh))), height, nil
+}
+
+// letterbox centers img on a width x height canvas filled with barColor
+func letterbox(img image.Image, width, height int, barColor color.Color) *image.RGBA {
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{C: barColor}, image.Point{}, draw.Src)
+	offset := image.Pt((width-img.Bounds().Dx())/2, (height-img.Bounds().Dy())/2)
+	draw.Draw(canvas, img.Bounds().Sub(img.Bounds().Min).Add(offset), img, img.Bounds().Min, draw.Src)
+	return canvas
+}
+
+// cropMarkMargin returns the width of the margin holding crop marks around a width x height image
+func cropMarkMargin(width, height int) int {
+	return max(16, min(width, height)/10)
+}
+
+// withCropMarks extends img by a margin filled with bg and draws 1px crop marks in markColor
+// continuing each image edge outward from every corner, with a gap so marks never touch the image
+func withCropMarks(img image.Image, markColor, bg color.Color) *image.RGBA {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	margin := cropMarkMargin(width, height)
+	canvas := letterbox(img, width+2*margin, height+2*margin, bg)
+
+	gap := margin / 4
+	mark := &image.Uniform{C: markColor}
+	left, top := margin, margin
+	right, bottom := margin+width-1, margin+height-1
+	outerRight, outerBottom := width+2*margin, height+2*margin
+	for _, r := range []image.Rectangle{
+		// horizontal marks along the top and bottom edges
+		image.Rect(0, top, left-gap, top+1),
+		image.Rect(right+1+gap, top, outerRight, top+1),
+		image.Rect(0, bottom, left-gap, bottom+1),
+		image.Rect(right+1+gap, bottom, outerRight, bottom+1),
+		// vertical marks along the left and right edges
+		image.Rect(left, 0, left+1, top-gap),
+		image.Rect(right, 0, right+1, top-gap),
+		image.Rect(left, bottom+1+gap, left+1, outerBottom),
+		image.Rect(right, bottom+1+gap, right+1, outerBottom),
+	} {
+		draw.Draw(canvas, r, mark, image.Point{}, draw.Src)
+	}
+	return canvas
+}
+
+// checkerboardTile is the edge length in pixels of a checkerboard preview square
+const checkerboardTile = 8
+
+// overCheckerboard composites img over a light/dark gray checkerboard
+func overCheckerboard(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	preview := image.NewRGBA(bounds)
+	light := color.RGBA{R: 0xee, G: 0xee, B: 0xee, A: 0xff}
+	dark := color.RGBA{R: 0xbb, G: 0xbb, B: 0xbb, A: 0xff}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if ((x-bounds.Min.X)/checkerboardTile+(y-bounds.Min.Y)/checkerboardTile)%2 == 0 {
+				preview.Set(x, y, light)
+			} else {
+				preview.Set(x, y, dark)
+			}
+		}
+	}
+	draw.Draw(preview, bounds, img, bounds.Min, draw.Over)
+	return preview
+}
+
+// GenerateGradientSwatch renders just a gradient, without a QR code, as a PNG, e.g. to preview
+// GradientStart, GradientEnd and GradientType in a color picker. Swatches are subject to the
+// default MaxPixels limit
+func GenerateGradientSwatch(width, height int, start, end, gradientType string) ([]byte, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("swatch size must be positive")
+	}
+	if width > defaultMaxPixels/height {
+		return nil, fmt.Errorf("image of %dx%d pixels exceeds the limit of %d pixels", width, height, defaultMaxPixels)
+	}
+	if start == "" || end == "" {
+		return nil, fmt.Errorf("gradient start and end colors are required")
+	}
+	mix, _ := gradientMix(parseColor(start), parseColor(end), "srgb")
+	return encodePNG(createGradient(width, height, mix, gradientType, 0.5, 0.5))
+}
+
+// applyGradient paints the dark modules of img with gradient composited over the background:
+// through mask when modules were drawn by a ModuleDrawer, otherwise wherever img has the
+// foreground color. Translucent gradient pixels, e.g. from GradientEdgeFade, blend with bg
+func applyGradient(img image.Image, gradient *image.RGBA, mask *image.Alpha, fg, bg color.Color) *image.RGBA {
+	bounds := img.Bounds()
+	if mask == nil {
+		mask = image.NewAlpha(bounds)
+		// Alpha is compared too: premultiplied, a transparent background matches black in RGB
+		fr, fgr, fb, fa := fg.RGBA()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				if r, g, b, a := img.At(x, y).RGBA(); r == fr && g == fgr && b == fb && a == fa {
+					mask.SetAlpha(x, y, color.Alpha{A: 255})
+				}
+			}
+		}
+	}
+	finalImg := image.NewRGBA(bounds)
+	draw.Draw(finalImg, bounds, image.NewUniform(bg), image.Point{}, draw.Src)
+	draw.DrawMask(finalImg, bounds, gradient, image.Point{}, mask, bounds.Min, draw.Over)
+	return finalImg
+}
+
+// fadeEdges scales the opacity of img down linearly with the distance from its center, by up to
+// fade at the edges and beyond
+func fadeEdges(img *image.RGBA, fade float64) {
+	if fade == 0 {
+		return
+	}
+	bounds := img.Bounds()
+	centerX, centerY := float64(bounds.Dx())/2, float64(bounds.Dy())/2
+	radius := math.Min(centerX, centerY)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			distance := math.Hypot(float64(x)+0.5-centerX, float64(y)+0.5-centerY)
+			factor := 1 - fade*math.Min(distance/radius, 1)
+			i := img.PixOffset(x, y)
+			// RGBA is premultiplied, so all channels scale together
+			for c := 0; c < 4; c++ {
+				img.Pix[i+c] = uint8(float64(img.Pix[i+c]) * factor)
+			}
+		}
+	}
+}
+
+// gradientMix returns the color at ratio (0-1) between start and end, interpolated in
+// colorSpace ("srgb" or "linear", default: "srgb")
+func gradientMix(start, end color.Color, colorSpace string) (func(ratio float64) color.RGBA, error) {
+	startR, startG, startB, _ := start.RGBA()
+	endR, endG, endB, _ := end.RGBA()
+	from := [3]float64{float64(startR >> 8), float64(startG >> 8), float64(startB >> 8)}
+	to := [3]float64{float64(endR >> 8), float64(endG >> 8), float64(endB >> 8)}
+	switch colorSpace {
+	case "", "srgb":
+		return func(ratio float64) color.RGBA {
+			var c [3]uint8
+			for i := range c {
+				c[i] = uint8(from[i] + ratio*(to[i]-from[i]))
+			}
+			return color.RGBA{c[0], c[1], c[2], 255}
+		}, nil
+	case "linear":
+		for i := range from {
+			from[i], to[i] = srgbToLinear(from[i]/255), srgbToLinear(to[i]/255)
+		}
+		return func(ratio float64) color.RGBA {
+			var c [3]uint8
+			for i := range c {
+				c[i] = uint8(math.Round(linearToSRGB(from[i]+ratio*(to[i]-from[i])) * 255))
+			}
+			return color.RGBA{c[0], c[1], c[2], 255}
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported gradient color space %q: must be srgb or linear", colorSpace)
+	}
+}
+
+// bilinearGradient creates a gradient image blending the corner colors (top left, top right,
+// bottom left, bottom right) bilinearly in colorSpace
+func bilinearGradient(width, height int, corners [4]color.Color, colorSpace string) (*image.RGBA, error) {
+	var values [4][3]float64
+	for i, c := range corners {
+		r, g, b, _ := c.RGBA()
+		values[i] = [3]float64{float64(r>>8) / 255, float64(g>>8) / 255, float64(b>>8) / 255}
+	}
+	encode := func(v float64) uint8 { return uint8(math.Round(v * 255)) }
+	switch colorSpace {
+	case "", "srgb":
+	case "linear":
+		for i := range values {
+			for j := range values[i] {
+				values[i][j] = srgbToLinear(values[i][j])
+			}
+		}
+		encode = func(v float64) uint8 { return uint8(math.Round(linearToSRGB(v) * 255)) }
+	default:
+		return nil, fmt.Errorf("unsupported gradient color space %q: must be srgb or linear", colorSpace)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		fy := float64(y) / float64(max(1, height-1))
+		for x := 0; x < width; x++ {
+			fx := float64(x) / float64(max(1, width-1))
+			var c [3]uint8
+			for i := range c {
+				top := values[0][i] + fx*(values[1][i]-values[0][i])
+				bottom := values[2][i] + fx*(values[3][i]-values[2][i])
+				c[i] = encode(top + fy*(bottom-top))
+			}
+			img.SetRGBA(x, y, color.RGBA{c[0], c[1], c[2], 255})
+		}
+	}
+	return img, nil
+}
+
+// srgbToLinear converts an sRGB component (0-1) to linear light
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB converts a linear-light component (0-1) to sRGB
+func linearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// createGradient renders a width x height gradient, coloring each pixel with mix. Radial
+// gradients are centered at the fractions (centerX, centerY) of the size and reach the end
+// color at the farthest corner
+func createGradient(width, height int, mix func(ratio float64) color.RGBA, gradientType string, centerX, centerY float64) *image.RGBA {
 	img := image.NewRGBA(image.Rect(0, 0, width, height))
-	startR, startG, startB, _ := startColor.RGBA()
-	endR, endG, endB, _ := endColor.RGBA()
-	startR, startG, startB = startR>>8, startG>>8, startB>>8
-	endR, endG, endB = endR>>8, endG>>8, endB>>8
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
 			var ratio float64
 			switch gradientType {
 			case "linear":
-				ratio = float64(x) / float64(width-1)
+				ratio = float64(x) / float64(max(1, width-1))
 			case "radial":
-				centerX, centerY := float64(width)/2, float64(height)/2
-				distance := math.Sqrt(math.Pow(float64(x)-centerX, 2) + math.Pow(float64(y)-centerY, 2))
-				maxDistance := math.Sqrt(math.Pow(centerX, 2) + math.Pow(centerY, 2))
+				cx, cy := float64(width)*centerX, float64(height)*centerY
+				distance := math.Sqrt(math.Pow(float64(x)-cx, 2) + math.Pow(float64(y)-cy, 2))
+				maxDistance := math.Hypot(math.Max(cx, float64(width)-cx), math.Max(cy, float64(height)-cy))
 				ratio = math.Min(distance/maxDistance, 1.0)
 			default:
-				ratio = float64(x) / float64(width-1)
+				ratio = float64(x) / float64(max(1, width-1))
 			}
-			r := uint8(float64(startR) + ratio*float64(int(endR)-int(startR)))
-			g := uint8(float64(startG) + ratio*float64(int(endG)-int(startG)))
-			b := uint8(float64(startB) + ratio*float64(int(endB)-int(startB)))
-			img.Set(x, y, color.RGBA{r, g, b, 255})
+			img.SetRGBA(x, y, mix(ratio))
 		}
 	}
 	return img
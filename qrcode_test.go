@@ -2,10 +2,50 @@ package qrcode
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
 	"image/png"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/gofont/goregular"
 )
 
+// testLogoHosts allows the loopback address logo servers listen on
+var testLogoHosts = []string{"127.0.0.1"}
+
+// newLogoServer serves a solid-color PNG logo of the given dimensions
+func newLogoServer(t *testing.T, width, height int, c color.Color) *httptest.Server {
+	t.Helper()
+	logo := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(logo, logo.Bounds(), &image.Uniform{C: c}, image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, logo); err != nil {
+		t.Fatalf("failed to encode logo: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(buf.Bytes())
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
 func TestNew(t *testing.T) {
 	generator := New()
 	if generator == nil {
@@ -103,6 +143,35 @@ func TestGeneratePNG_ErrorCorrection(t *testing.T) {
 	}
 }
 
+func TestGeneratePNG_DataTooLong(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		level   string
+		wantErr bool
+	}{
+		{name: "byte at capacity", data: strings.Repeat("a", 2331), level: "M"},
+		{name: "byte over capacity", data: strings.Repeat("a", 2332), level: "M", wantErr: true},
+		{name: "numeric at capacity", data: strings.Repeat("7", 5596), level: "M"},
+		{name: "numeric over capacity", data: strings.Repeat("7", 5597), level: "M", wantErr: true},
+		{name: "alphanumeric at capacity", data: strings.Repeat("A", 1852), level: "H"},
+		{name: "alphanumeric over capacity", data: strings.Repeat("A", 1853), level: "H", wantErr: true},
+		{name: "mixed beyond byte capacity", data: "a" + strings.Repeat("7", 3000), level: "M"},
+		{name: "beyond any capacity", data: strings.Repeat("7", 8000), level: "L", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := GeneratePNG(Options{Data: tt.data, Size: 100, Error: tt.level, Fast: true})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GeneratePNG() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrDataTooLong) {
+				t.Errorf("GeneratePNG() error = %v, want ErrDataTooLong", err)
+			}
+		})
+	}
+}
+
 func TestGeneratePNG_Colors(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -168,6 +237,90 @@ func TestGeneratePNG_Colors(t *testing.T) {
 	}
 }
 
+func TestGeneratePNG_Invert(t *testing.T) {
+	black := color.RGBA{A: 255}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+	tests := []struct {
+		invert     bool
+		quietZone  color.RGBA
+		finderEdge color.RGBA
+	}{
+		{false, white, black},
+		{true, black, white},
+	}
+
+	for _, tt := range tests {
+		pngData, err := GeneratePNG(Options{
+			Data:       "https://example.com",
+			Size:       330,
+			Foreground: "black",
+			Background: "white",
+			Border:     4,
+			Invert:     tt.invert,
+		})
+		if err != nil {
+			t.Fatalf("GeneratePNG() error = %v", err)
+		}
+		img, err := png.Decode(bytes.NewReader(pngData))
+		if err != nil {
+			t.Fatalf("GeneratePNG() returned invalid PNG: %v", err)
+		}
+
+		// 33 modules including the quiet zone at 10px each; the finder starts at module 4
+		if got := color.RGBAModel.Convert(img.At(5, 5)).(color.RGBA); got != tt.quietZone {
+			t.Errorf("Invert=%v: quiet zone = %v, want %v", tt.invert, got, tt.quietZone)
+		}
+		if got := color.RGBAModel.Convert(img.At(45, 45)).(color.RGBA); got != tt.finderEdge {
+			t.Errorf("Invert=%v: finder edge = %v, want %v", tt.invert, got, tt.finderEdge)
+		}
+	}
+}
+
+func TestGenerateImage_DarkMode(t *testing.T) {
+	light := color.RGBA{R: 230, G: 230, B: 230, A: 255}
+	dark := color.RGBA{R: 18, G: 18, B: 18, A: 255}
+	black := color.RGBA{A: 255}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+	tests := []struct {
+		name       string
+		foreground string
+		background string
+		wantFg     color.RGBA
+		wantBg     color.RGBA
+	}{
+		{name: "defaults", wantFg: light, wantBg: dark},
+		{name: "bright foreground keeps dark background", foreground: "rgb(255,200,0)", wantFg: color.RGBA{R: 255, G: 200, A: 255}, wantBg: dark},
+		{name: "dark foreground gets white background", foreground: "rgb(30,30,30)", wantFg: color.RGBA{R: 30, G: 30, B: 30, A: 255}, wantBg: white},
+		{name: "dark background keeps light foreground", background: "rgb(0,0,128)", wantFg: light, wantBg: color.RGBA{B: 128, A: 255}},
+		{name: "light background gets black foreground", background: "white", wantFg: black, wantBg: white},
+		{name: "explicit colors override", foreground: "red", background: "blue", wantFg: color.RGBA{R: 255, A: 255}, wantBg: color.RGBA{B: 255, A: 255}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := GenerateImage(Options{
+				Data:       "https://example.com",
+				Size:       330,
+				Foreground: tt.foreground,
+				Background: tt.background,
+				Border:     4,
+				DarkMode:   true,
+			})
+			if err != nil {
+				t.Fatalf("GenerateImage() error = %v", err)
+			}
+			// 33 modules including the quiet zone at 10px each; the finder starts at module 4
+			if got := color.RGBAModel.Convert(img.At(5, 5)).(color.RGBA); got != tt.wantBg {
+				t.Errorf("background = %v, want %v", got, tt.wantBg)
+			}
+			if got := color.RGBAModel.Convert(img.At(45, 45)).(color.RGBA); got != tt.wantFg {
+				t.Errorf("foreground = %v, want %v", got, tt.wantFg)
+			}
+		})
+	}
+}
+
 func TestGeneratePNG_Border(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -288,158 +441,2846 @@ func TestGeneratePNG_Gradient(t *testing.T) {
 	}
 }
 
-func TestGeneratePNG_LogoSize(t *testing.T) {
+func TestCreateGradient_RadialCenter(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
 	tests := []struct {
-		name     string
-		logoSize float64
-		wantErr  bool
+		name             string
+		centerX, centerY float64
+		start            image.Point
+		farthest         image.Point
 	}{
-		{
-			name:     "default logo size",
-			logoSize: 0,
-			wantErr:  false, // Should use default 20.0
-		},
-		{
-			name:     "small logo",
-			logoSize: 10.0,
-			wantErr:  false,
-		},
-		{
-			name:     "large logo",
-			logoSize: 30.0,
-			wantErr:  false,
-		},
-		{
-			name:     "negative logo size",
-			logoSize: -5.0,
-			wantErr:  false, // Should use default
-		},
+		{name: "centered", centerX: 0.5, centerY: 0.5, start: image.Pt(50, 50), farthest: image.Pt(0, 0)},
+		{name: "left of center", centerX: 0.25, centerY: 0.5, start: image.Pt(25, 50), farthest: image.Pt(100, 0)},
+		{name: "top left corner", centerX: 0, centerY: 0, start: image.Pt(0, 0), farthest: image.Pt(100, 100)},
 	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			opts := Options{
-				Data:     "https://example.com",
-				Size:     500,
-				LogoSize: tt.logoSize,
-				// Note: LogoURL is empty, so logo won't be embedded
-				// This just tests that LogoSize doesn't cause errors
-			}
-			pngData, err := GeneratePNG(opts)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("GeneratePNG() error = %v, wantErr %v", err, tt.wantErr)
-				return
+			mix, _ := gradientMix(red, blue, "srgb")
+			gradient := createGradient(100, 100, mix, "radial", tt.centerX, tt.centerY)
+			if got := gradient.RGBAAt(tt.start.X, tt.start.Y); got != red {
+				t.Errorf("center pixel = %v, want the start color", got)
 			}
-			if !tt.wantErr && len(pngData) == 0 {
-				t.Error("GeneratePNG() returned empty PNG")
+			// The farthest corner lies just outside the image; its neighboring pixel is almost blue
+			corner := image.Pt(min(tt.farthest.X, 99), min(tt.farthest.Y, 99))
+			if got := gradient.RGBAAt(corner.X, corner.Y); got.B < 245 {
+				t.Errorf("farthest corner pixel = %v, want nearly the end color", got)
 			}
 		})
 	}
-}
 
-func TestGenerator_GeneratePNG(t *testing.T) {
-	generator := New()
-	if generator == nil {
-		t.Fatal("New() returned nil")
+	if _, err := GenerateImage(Options{Data: "test", GradientStart: "red", GradientEnd: "blue", GradientType: "radial", GradientCenterX: 1.5}); err == nil {
+		t.Error("GenerateImage() with a gradient center outside 0-1 should fail")
 	}
 
-	opts := Options{
-		Data: "https://example.com",
-		Size: 300,
+	// Both center coordinates at 0 mean unset, not the top left corner
+	unset, err := GeneratePNG(Options{Data: "test", GradientStart: "red", GradientEnd: "blue", GradientType: "radial"})
+	if err != nil {
+		t.Fatalf("GeneratePNG() error = %v", err)
 	}
-
-	pngData, err := generator.GeneratePNG(opts)
+	centered, err := GeneratePNG(Options{Data: "test", GradientStart: "red", GradientEnd: "blue", GradientType: "radial", GradientCenterX: 0.5, GradientCenterY: 0.5})
 	if err != nil {
-		t.Errorf("Generator.GeneratePNG() error = %v", err)
-		return
+		t.Fatalf("GeneratePNG() error = %v", err)
 	}
-
-	if len(pngData) == 0 {
-		t.Error("Generator.GeneratePNG() returned empty PNG")
+	if !bytes.Equal(unset, centered) {
+		t.Error("GeneratePNG() with an unset gradient center should match a centered one")
 	}
+}
 
-	// Verify it's a valid PNG
-	_, err = png.Decode(bytes.NewReader(pngData))
+func TestGenerateImage_GradientCorners(t *testing.T) {
+	// Version 1 without border: the top left, top right and bottom left pixels are finder
+	// modules, so they take the corner colors
+	opts := Options{
+		Data:            "test",
+		Size:            210,
+		Foreground:      "black",
+		Background:      "white",
+		Error:           "L",
+		GradientStart:   "black",
+		GradientEnd:     "black",
+		GradientCorners: [4]string{"red", "green", "blue", "white"},
+	}
+	img, err := GenerateImage(opts)
 	if err != nil {
-		t.Errorf("Generator.GeneratePNG() returned invalid PNG: %v", err)
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+	tests := []struct {
+		name string
+		x, y int
+		want color.RGBA
+	}{
+		{"top left", 0, 0, color.RGBA{R: 255, A: 255}},
+		{"top right", 209, 0, color.RGBA{G: 255, A: 255}},
+		{"bottom left", 0, 209, color.RGBA{B: 255, A: 255}},
+	}
+	for _, tt := range tests {
+		if got := color.RGBAModel.Convert(img.At(tt.x, tt.y)).(color.RGBA); got != tt.want {
+			t.Errorf("%s: pixel (%d,%d) = %v, want %v", tt.name, tt.x, tt.y, got, tt.want)
+		}
+	}
+
+	for _, corners := range [][4]string{
+		{"red", "green", "blue", ""},
+		{"red", "green", "blue", "#zzz"},
+	} {
+		invalid := opts
+		invalid.GradientCorners = corners
+		if _, err := GenerateImage(invalid); err == nil {
+			t.Errorf("GenerateImage() with gradient corners %q should fail", corners)
+		}
 	}
 }
 
-func TestGenerator_MultipleCalls(t *testing.T) {
-	generator := New()
+func TestBilinearGradient(t *testing.T) {
+	black := color.RGBA{A: 255}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	tests := []struct {
+		colorSpace string
+		want       uint8
+	}{
+		{"srgb", 128},
+		{"linear", 188},
+	}
+	for _, tt := range tests {
+		t.Run(tt.colorSpace, func(t *testing.T) {
+			gradient, err := bilinearGradient(3, 3, [4]color.Color{black, white, white, black}, tt.colorSpace)
+			if err != nil {
+				t.Fatalf("bilinearGradient() error = %v", err)
+			}
+			if got := gradient.RGBAAt(0, 0); got != black {
+				t.Errorf("top left = %v, want %v", got, black)
+			}
+			if got := gradient.RGBAAt(2, 0); got != white {
+				t.Errorf("top right = %v, want %v", got, white)
+			}
+			if got := gradient.RGBAAt(1, 1).R; got != tt.want {
+				t.Errorf("center red = %d, want %d", got, tt.want)
+			}
+		})
+	}
 
-	opts1 := Options{
-		Data: "https://example.com/first",
-		Size: 300,
+	if _, err := bilinearGradient(3, 3, [4]color.Color{black, white, white, black}, "hsl"); err == nil {
+		t.Error("bilinearGradient() with an unknown color space should fail")
 	}
+}
 
-	opts2 := Options{
-		Data: "https://example.com/second",
-		Size: 300,
+func TestGradientMix(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	green := color.RGBA{G: 255, A: 255}
+	tests := []struct {
+		colorSpace string
+		ratio      float64
+		want       color.RGBA
+	}{
+		{"", 0.5, color.RGBA{R: 127, G: 127, A: 255}},
+		{"srgb", 0.5, color.RGBA{R: 127, G: 127, A: 255}},
+		{"linear", 0, red},
+		{"linear", 0.5, color.RGBA{R: 188, G: 188, A: 255}},
+		{"linear", 1, green},
+	}
+	for _, tt := range tests {
+		mix, err := gradientMix(red, green, tt.colorSpace)
+		if err != nil {
+			t.Fatalf("gradientMix(%q) error = %v", tt.colorSpace, err)
+		}
+		if got := mix(tt.ratio); got != tt.want {
+			t.Errorf("gradientMix(%q)(%v) = %v, want %v", tt.colorSpace, tt.ratio, got, tt.want)
+		}
 	}
 
-	pngData1, err1 := generator.GeneratePNG(opts1)
-	pngData2, err2 := generator.GeneratePNG(opts2)
+	if _, err := GenerateImage(Options{Data: "test", GradientStart: "red", GradientEnd: "blue", GradientColorSpace: "hsl"}); err == nil {
+		t.Error("GenerateImage() with an unknown gradient color space should fail")
+	}
+}
 
-	if err1 != nil {
-		t.Errorf("First GeneratePNG() error = %v", err1)
+func TestGenerateGradientSwatch(t *testing.T) {
+	data, err := GenerateGradientSwatch(100, 20, "rgb(255,0,0)", "rgb(0,0,255)", "linear")
+	if err != nil {
+		t.Fatalf("GenerateGradientSwatch() error = %v", err)
 	}
-	if err2 != nil {
-		t.Errorf("Second GeneratePNG() error = %v", err2)
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("GenerateGradientSwatch() returned invalid PNG: %v", err)
 	}
-
-	if len(pngData1) == 0 {
-		t.Error("First GeneratePNG() returned empty PNG")
+	if got := img.Bounds(); got != image.Rect(0, 0, 100, 20) {
+		t.Errorf("bounds = %v, want 100x20", got)
 	}
-	if len(pngData2) == 0 {
-		t.Error("Second GeneratePNG() returned empty PNG")
+	if got := color.RGBAModel.Convert(img.At(0, 10)).(color.RGBA); got != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("left edge = %v, want the start color", got)
+	}
+	if got := color.RGBAModel.Convert(img.At(99, 10)).(color.RGBA); got != (color.RGBA{B: 255, A: 255}) {
+		t.Errorf("right edge = %v, want the end color", got)
 	}
 
-	// Verify both are valid PNGs
-	_, err := png.Decode(bytes.NewReader(pngData1))
+	data, err = GenerateGradientSwatch(1, 5, "red", "blue", "linear")
 	if err != nil {
-		t.Errorf("First PNG is invalid: %v", err)
+		t.Fatalf("GenerateGradientSwatch() 1 pixel wide error = %v", err)
 	}
-
-	_, err = png.Decode(bytes.NewReader(pngData2))
+	img, err = png.Decode(bytes.NewReader(data))
 	if err != nil {
-		t.Errorf("Second PNG is invalid: %v", err)
+		t.Fatalf("GenerateGradientSwatch() returned invalid PNG: %v", err)
+	}
+	if got := color.RGBAModel.Convert(img.At(0, 2)).(color.RGBA); got != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("1 pixel wide swatch = %v, want the start color", got)
 	}
 
-	// PNGs with different data should produce different results
-	// (though exact byte comparison might fail due to compression)
-	if len(pngData1) == len(pngData2) && bytes.Equal(pngData1, pngData2) {
-		// If they're identical, at least verify they're valid
-		// This can happen with very similar QR codes, but is unlikely
-		t.Logf("Warning: PNGs with different data produced identical output (size: %d bytes)", len(pngData1))
+	for _, tt := range []struct {
+		name          string
+		width, height int
+		start, end    string
+	}{
+		{"zero width", 0, 20, "red", "blue"},
+		{"negative height", 100, -1, "red", "blue"},
+		{"missing end color", 100, 20, "red", ""},
+		{"over pixel limit", 5000, 5000, "red", "blue"},
+		{"overflowing size", math.MaxInt, math.MaxInt, "red", "blue"},
+	} {
+		if _, err := GenerateGradientSwatch(tt.width, tt.height, tt.start, tt.end, "linear"); err == nil {
+			t.Errorf("%s: GenerateGradientSwatch() should fail", tt.name)
+		}
 	}
 }
 
-func TestParseColor(t *testing.T) {
+func TestGeneratePNG_TransparentBackground(t *testing.T) {
 	tests := []struct {
-		name  string
-		input string
-		valid bool
+		name     string
+		bg       string
+		gradient bool
 	}{
-		{"black", "black", true},
-		{"white", "white", true},
-		{"red", "red", true},
-		{"green", "green", true},
-		{"blue", "blue", true},
-		{"RGB format", "rgb(255,0,0)", true},
-		{"RGBA format", "rgba(255,0,0,128)", true},
-		{"invalid format", "invalid", true}, // Should return default (black)
-		{"empty string", "", true},          // Should return default
+		{name: "named", bg: "transparent"},
+		{name: "named ignores case", bg: "Transparent"},
+		{name: "rgba with zero alpha", bg: "rgba(255,255,255,0)"},
+		{name: "with gradient", bg: "transparent", gradient: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{Data: "https://example.com", Size: 300, Border: 4, Background: tt.bg}
+			if tt.gradient {
+				opts.GradientStart, opts.GradientEnd = "red", "blue"
+			}
+			data, err := GeneratePNG(opts)
+			if err != nil {
+				t.Fatalf("GeneratePNG() error = %v", err)
+			}
+			img, err := png.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("png.Decode() error = %v", err)
+			}
+			if _, _, _, a := img.At(0, 0).RGBA(); a != 0 {
+				t.Errorf("corner alpha = %d, want 0", a>>8)
+			}
+			// The first pixel along the diagonal that is not transparent lies in the dark
+			// outer ring of the top left finder pattern
+			for d := 0; d < 150; d++ {
+				if _, _, _, a := img.At(d, d).RGBA(); a != 0 {
+					if a != 0xffff {
+						t.Errorf("finder pixel (%d,%d) alpha = %d, want 255", d, d, a>>8)
+					}
+					return
+				}
+			}
+			t.Error("no opaque module found along the diagonal")
+		})
 	}
+}
 
+func TestGenerateImage_TranslucentBackground(t *testing.T) {
+	near := func(a, b color.NRGBA) bool {
+		d := func(x, y uint8) bool { return max(x, y)-min(x, y) <= 2 }
+		return d(a.R, b.R) && d(a.G, b.G) && d(a.B, b.B) && d(a.A, b.A)
+	}
+	tests := []struct {
+		name string
+		bg   string
+		want color.NRGBA // of the quiet zone corner
+		jpeg color.NRGBA // of the quiet zone corner composited onto white
+	}{
+		{"half transparent white", "rgba(255,255,255,128)", color.NRGBA{R: 255, G: 255, B: 255, A: 128}, color.NRGBA{R: 255, G: 255, B: 255, A: 255}},
+		{"half transparent blue", "rgba(0,0,200,128)", color.NRGBA{B: 200, A: 128}, color.NRGBA{R: 127, G: 127, B: 227, A: 255}},
+		{"fully transparent blue", "rgba(0,0,100,0)", color.NRGBA{}, color.NRGBA{R: 255, G: 255, B: 255, A: 255}},
+	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			color := parseColor(tt.input)
-			if color == nil {
-				t.Error("parseColor() returned nil")
+			opts := Options{Data: "https://example.com", Size: 300, Border: 4, Background: tt.bg}
+			img, err := GenerateImage(opts)
+			if err != nil {
+				t.Fatalf("GenerateImage() error = %v", err)
+			}
+			got := color.NRGBAModel.Convert(img.At(0, 0)).(color.NRGBA)
+			if got.A != tt.want.A || (got.A != 0 && !near(got, tt.want)) {
+				t.Errorf("corner = %v, want %v", got, tt.want)
+			}
+
+			data, err := GenerateJPEG(opts, 100)
+			if err != nil {
+				t.Fatalf("GenerateJPEG() error = %v", err)
+			}
+			decoded, err := jpeg.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("jpeg.Decode() error = %v", err)
+			}
+			if flat := color.NRGBAModel.Convert(decoded.At(1, 1)).(color.NRGBA); !near(flat, tt.jpeg) {
+				t.Errorf("jpeg corner = %v, want %v", flat, tt.jpeg)
+			}
+		})
+	}
+}
+
+func TestGeneratePNG_GradientEdgeFade(t *testing.T) {
+	tests := []struct {
+		name      string
+		fade      float64
+		wantAlpha uint8 // of the dark top left corner module over a transparent background
+		wantErr   bool
+	}{
+		{name: "no fade", fade: 0, wantAlpha: 255},
+		{name: "half fade", fade: 0.5, wantAlpha: 127},
+		{name: "full fade", fade: 1, wantAlpha: 0},
+		{name: "negative", fade: -0.1, wantErr: true},
+		{name: "above one", fade: 1.5, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := GenerateImage(Options{
+				Data:             "https://example.com",
+				Size:             300,
+				Foreground:       "black",
+				Background:       "rgba(0,0,0,0)",
+				GradientStart:    "rgb(255,0,0)",
+				GradientEnd:      "rgb(0,0,255)",
+				GradientEdgeFade: tt.fade,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateImage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			corner := color.NRGBAModel.Convert(img.At(0, 0)).(color.NRGBA)
+			if corner.A != tt.wantAlpha {
+				t.Errorf("corner alpha = %d, want %d", corner.A, tt.wantAlpha)
+			}
+			center := color.NRGBAModel.Convert(img.At(150, 150)).(color.NRGBA)
+			if center.A < 250 {
+				t.Errorf("center alpha = %d, want (nearly) opaque", center.A)
 			}
 		})
 	}
+
+	// Over an opaque background the faded modules blend into it instead
+	img, err := GenerateImage(Options{
+		Data:             "https://example.com",
+		Size:             300,
+		Foreground:       "black",
+		Background:       "white",
+		GradientStart:    "rgb(255,0,0)",
+		GradientEnd:      "rgb(0,0,255)",
+		GradientEdgeFade: 1,
+	})
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+	if got := color.RGBAModel.Convert(img.At(0, 0)).(color.RGBA); got != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("fully faded corner over white = %v, want white", got)
+	}
+}
+
+func TestGeneratePNG_LightModuleColor(t *testing.T) {
+	// Version 2 (25 modules) plus a 4 module quiet zone on each side at 10px per module
+	tint := color.RGBA{R: 230, G: 240, B: 255, A: 255}
+	img, err := GenerateImage(Options{
+		Data:             "https://example.com",
+		Size:             330,
+		Foreground:       "black",
+		Background:       "white",
+		Border:           quietZoneModules,
+		GradientStart:    "rgb(255,0,0)",
+		GradientEnd:      "rgb(0,0,255)",
+		LightModuleColor: "rgb(230,240,255)",
+	})
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		module image.Point // including the quiet zone
+		want   func(color.RGBA) bool
+	}{
+		{"quiet zone", image.Pt(1, 1), func(c color.RGBA) bool { return c == color.RGBA{255, 255, 255, 255} }},
+		{"separator", image.Pt(11, 4), func(c color.RGBA) bool { return c == tint }},
+		{"finder", image.Pt(4, 4), func(c color.RGBA) bool { return c != tint && c.G == 0 }},
+	}
+	for _, tt := range tests {
+		got := color.RGBAModel.Convert(img.At(tt.module.X*10+5, tt.module.Y*10+5)).(color.RGBA)
+		if !tt.want(got) {
+			t.Errorf("%s: unexpected pixel color %v", tt.name, got)
+		}
+	}
+}
+
+func TestGeneratePNG_EyeColor(t *testing.T) {
+	// Version 2 (25 modules) at 10px per module
+	base := Options{
+		Data:          "https://example.com",
+		Size:          250,
+		Foreground:    "black",
+		Background:    "white",
+		GradientStart: "rgb(255,0,0)",
+		GradientEnd:   "rgb(0,0,255)",
+		EyeColor:      "rgb(0,128,0)",
+	}
+	eye := color.RGBA{G: 128, A: 255}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+	tests := []struct {
+		name   string
+		drawer ModuleDrawer
+		x, y   int
+		want   func(color.RGBA) bool
+	}{
+		{"finder ring", nil, 5, 5, func(c color.RGBA) bool { return c == eye }},
+		{"finder center", nil, 35, 35, func(c color.RGBA) bool { return c == eye }},
+		{"top right finder", nil, 245, 5, func(c color.RGBA) bool { return c == eye }},
+		{"alignment keeps gradient", nil, 185, 185, func(c color.RGBA) bool { return c != eye && c != white }},
+		{"drawn shape", CircleDrawer{}, 5, 5, func(c color.RGBA) bool { return c == eye }},
+		{"outside drawn shape", CircleDrawer{}, 0, 0, func(c color.RGBA) bool { return c == white }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := base
+			opts.ModuleDrawer = tt.drawer
+			img, err := GenerateImage(opts)
+			if err != nil {
+				t.Fatalf("GenerateImage() error = %v", err)
+			}
+			got := color.RGBAModel.Convert(img.At(tt.x, tt.y)).(color.RGBA)
+			if !tt.want(got) {
+				t.Errorf("unexpected pixel color %v at (%d,%d)", got, tt.x, tt.y)
+			}
+		})
+	}
+}
+
+func TestGeneratePNG_AlignmentColor(t *testing.T) {
+	// "https://example.com" at level M is a version 2 symbol (25x25 modules) whose single
+	// alignment pattern is centered on module (18,18); 250px gives 10px per module
+	pngData, err := GeneratePNG(Options{
+		Data:           "https://example.com",
+		Size:           250,
+		Foreground:     "black",
+		Background:     "white",
+		AlignmentColor: "red",
+	})
+	if err != nil {
+		t.Fatalf("GeneratePNG() error = %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(pngData))
+	if err != nil {
+		t.Fatalf("GeneratePNG() returned invalid PNG: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		module image.Point
+		want   color.RGBA
+	}{
+		{"alignment center", image.Pt(18, 18), color.RGBA{R: 255, A: 255}},
+		{"alignment ring", image.Pt(16, 16), color.RGBA{R: 255, A: 255}},
+		{"alignment light ring", image.Pt(17, 17), color.RGBA{R: 255, G: 255, B: 255, A: 255}},
+		{"finder center", image.Pt(3, 3), color.RGBA{A: 255}},
+	}
+	for _, tt := range tests {
+		got := color.RGBAModel.Convert(img.At(tt.module.X*10+5, tt.module.Y*10+5)).(color.RGBA)
+		if got != tt.want {
+			t.Errorf("%s: pixel color = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateImage_TimingColor(t *testing.T) {
+	// Version 2 (25x25 modules) at 10px per module: the timing patterns run along row and
+	// column 6 from module 8 to 16, dark on even modules
+	img, err := GenerateImage(Options{
+		Data:        "https://example.com",
+		Size:        250,
+		Foreground:  "black",
+		Background:  "white",
+		TimingColor: "blue",
+	})
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		module image.Point
+		want   color.RGBA
+	}{
+		{"horizontal timing dark", image.Pt(10, 6), color.RGBA{B: 255, A: 255}},
+		{"horizontal timing light", image.Pt(9, 6), color.RGBA{R: 255, G: 255, B: 255, A: 255}},
+		{"vertical timing dark", image.Pt(6, 16), color.RGBA{B: 255, A: 255}},
+		{"finder edge", image.Pt(6, 5), color.RGBA{A: 255}},
+	}
+	for _, tt := range tests {
+		got := color.RGBAModel.Convert(img.At(tt.module.X*10+5, tt.module.Y*10+5)).(color.RGBA)
+		if got != tt.want {
+			t.Errorf("%s: pixel color = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGeneratePNG_EyeBallShape(t *testing.T) {
+	black := color.RGBA{A: 255}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+	// 250px for a 25x25 module symbol gives 10px modules; the top left eyeball spans
+	// pixels 20-49 with its center at (35,35)
+	tests := []struct {
+		shape  string
+		light  string
+		corner color.RGBA
+	}{
+		{"", "", black},
+		{"square", "", black},
+		{"circle", "", white},
+		{"circle", "rgb(255,255,0)", color.RGBA{R: 255, G: 255, A: 255}},
+	}
+
+	for _, tt := range tests {
+		t.Run("shape_"+tt.shape+"_"+tt.light, func(t *testing.T) {
+			pngData, err := GeneratePNG(Options{
+				Data:             "https://example.com",
+				Size:             250,
+				Foreground:       "black",
+				Background:       "white",
+				EyeBallShape:     tt.shape,
+				LightModuleColor: tt.light,
+			})
+			if err != nil {
+				t.Fatalf("GeneratePNG() error = %v", err)
+			}
+			img, err := png.Decode(bytes.NewReader(pngData))
+			if err != nil {
+				t.Fatalf("GeneratePNG() returned invalid PNG: %v", err)
+			}
+
+			at := func(x, y int) color.RGBA {
+				return color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			}
+			if got := at(20, 20); got != tt.corner {
+				t.Errorf("eyeball corner = %v, want %v", got, tt.corner)
+			}
+			for _, p := range []image.Point{{35, 35}, {21, 35}, {35, 21}, {48, 35}, {35, 48}} {
+				if got := at(p.X, p.Y); got != black {
+					t.Errorf("eyeball pixel %v = %v, want black", p, got)
+				}
+			}
+			// The finder ring stays square
+			if got := at(0, 0); got != black {
+				t.Errorf("finder ring corner = %v, want black", got)
+			}
+		})
+	}
+
+	if _, err := GeneratePNG(Options{Data: "https://example.com", EyeBallShape: "cirle"}); err == nil ||
+		!strings.Contains(err.Error(), `unsupported eye ball shape "cirle"`) {
+		t.Errorf("GeneratePNG() with an unknown eye ball shape error = %v", err)
+	}
+}
+
+func TestGeneratePNG_PayloadWrapper(t *testing.T) {
+	wrapped, err := ChecksumWrapper("v1")("https://example.com")
+	if err != nil {
+		t.Fatalf("ChecksumWrapper() error = %v", err)
+	}
+	want, err := GeneratePNG(Options{Data: wrapped})
+	if err != nil {
+		t.Fatalf("GeneratePNG() error = %v", err)
+	}
+	got, err := GeneratePNG(Options{Data: "https://example.com", PayloadWrapper: ChecksumWrapper("v1")})
+	if err != nil {
+		t.Fatalf("GeneratePNG() with PayloadWrapper error = %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("GeneratePNG() with PayloadWrapper should encode the wrapped data")
+	}
+
+	failing := func(string) (string, error) { return "", errors.New("envelope unavailable") }
+	if _, err := GeneratePNG(Options{Data: "test", PayloadWrapper: failing}); err == nil {
+		t.Error("GeneratePNG() should fail when the payload wrapper fails")
+	}
+}
+
+func TestGenerateWithInfo_VersionBounds(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		minVersion  int
+		maxVersion  int
+		wantVersion int
+		wantErr     bool
+	}{
+		{"auto selection", "https://example.com", 0, 0, 2, false},
+		{"raised to min version", "https://example.com", 3, 10, 3, false},
+		{"within bounds", "https://example.com", 1, 10, 2, false},
+		{"exceeds max version", "https://example.com/a/much/longer/path/than/fits/in/version/one", 0, 1, 0, true},
+		{"min above max", "https://example.com", 5, 3, 0, true},
+		{"min out of range", "https://example.com", 41, 0, 0, true},
+		{"max out of range", "https://example.com", 0, -1, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pngData, info, err := GenerateWithInfo(Options{
+				Data:       tt.data,
+				Size:       300,
+				MinVersion: tt.minVersion,
+				MaxVersion: tt.maxVersion,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateWithInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(pngData) == 0 {
+				t.Error("GenerateWithInfo() returned empty PNG")
+			}
+			if info.Version != tt.wantVersion {
+				t.Errorf("Info.Version = %d, want %d", info.Version, tt.wantVersion)
+			}
+			if want := 17 + 4*tt.wantVersion; info.Modules != want {
+				t.Errorf("Info.Modules = %d, want %d", info.Modules, want)
+			}
+		})
+	}
+}
+
+func TestGenerateWithInfo_RecoveryLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		level string
+		want  string
+	}{
+		{"default", "", "M"},
+		{"low", "L", "L"},
+		{"quartile", "Q", "Q"},
+		{"high", "H", "H"},
+		{"unrecognized", "X", "M"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, info, err := GenerateWithInfo(Options{Data: "https://example.com", Size: 200, Error: tt.level})
+			if err != nil {
+				t.Fatalf("GenerateWithInfo() error = %v", err)
+			}
+			if info.RecoveryLevel != tt.want {
+				t.Errorf("Info.RecoveryLevel = %q, want %q", info.RecoveryLevel, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateWithInfo_Fingerprint(t *testing.T) {
+	base := Options{Data: "https://example.com", Foreground: "black", Background: "white"}
+	fingerprint := func(opts Options) string {
+		t.Helper()
+		_, info, err := GenerateWithInfo(opts)
+		if err != nil {
+			t.Fatalf("GenerateWithInfo() error = %v", err)
+		}
+		if len(info.Fingerprint) != 64 {
+			t.Fatalf("Fingerprint = %q, want 64 hex digits", info.Fingerprint)
+		}
+		return info.Fingerprint
+	}
+	want := fingerprint(base)
+
+	gray := base
+	gray.ColorModel = "gray"
+	withMetadata := base
+	withMetadata.Metadata = map[string]string{"Comment": "same pixels"}
+	red := base
+	red.Foreground = "red"
+	larger := base
+	larger.Size = 325
+
+	tests := []struct {
+		name string
+		opts Options
+		same bool
+	}{
+		{name: "regenerated", opts: base, same: true},
+		{name: "different color model", opts: gray, same: true},
+		{name: "with metadata", opts: withMetadata, same: true},
+		{name: "different color", opts: red, same: false},
+		{name: "different size", opts: larger, same: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fingerprint(tt.opts); (got == want) != tt.same {
+				t.Errorf("fingerprint equal = %v, want %v", got == want, tt.same)
+			}
+		})
+	}
+}
+
+func TestGenerateWithInfo_PhysicalSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		wantSize int
+		wantErr  bool
+	}{
+		{name: "25.4mm at 300 DPI", opts: Options{PhysicalSize: PhysicalSize{WidthMM: 25.4, DPI: 300}}, wantSize: 300},
+		{name: "overrides Size", opts: Options{Size: 100, PhysicalSize: PhysicalSize{WidthMM: 30, DPI: 600}}, wantSize: 709},
+		// 8.5mm at 300 DPI is 100px: a 4 module quiet zone plus 96 extra pixels
+		{name: "border", opts: Options{PhysicalSize: PhysicalSize{WidthMM: 25.4, BorderMM: 8.5, DPI: 300}}, wantSize: 492},
+		{name: "missing DPI", opts: Options{PhysicalSize: PhysicalSize{WidthMM: 25.4}}, wantErr: true},
+		{name: "negative border", opts: Options{PhysicalSize: PhysicalSize{WidthMM: 25.4, BorderMM: -1, DPI: 300}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.Data = "https://example.com"
+			_, info, err := GenerateWithInfo(opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateWithInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && info.Size != tt.wantSize {
+				t.Errorf("Info.Size = %d, want %d", info.Size, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestGenerateWithInfo_SnapToModule(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		border   int
+		snap     bool
+		wantSize int
+	}{
+		{name: "already a multiple", size: 300, snap: true, wantSize: 300},
+		{name: "rounded up", size: 310, snap: true, wantSize: 325},
+		{name: "rounded up with quiet zone", size: 300, border: 4, snap: true, wantSize: 330},
+		{name: "smaller than module count", size: 10, snap: true, wantSize: 25},
+		{name: "not snapped", size: 310, wantSize: 310},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Version 2: 25 modules, 33 with the quiet zone
+			pngData, info, err := GenerateWithInfo(Options{
+				Data:         "https://example.com",
+				Size:         tt.size,
+				Border:       tt.border,
+				SnapToModule: tt.snap,
+			})
+			if err != nil {
+				t.Fatalf("GenerateWithInfo() error = %v", err)
+			}
+			if info.Size != tt.wantSize {
+				t.Errorf("Info.Size = %d, want %d", info.Size, tt.wantSize)
+			}
+			img, err := png.Decode(bytes.NewReader(pngData))
+			if err != nil {
+				t.Fatalf("GenerateWithInfo() returned invalid PNG: %v", err)
+			}
+			if got := img.Bounds().Dx(); got != tt.wantSize {
+				t.Errorf("image width = %d, want %d", got, tt.wantSize)
+			}
+		})
+	}
+
+	if _, _, err := GenerateWithInfo(Options{Data: "test", SnapToModule: true, ForceExactSize: true}); err == nil {
+		t.Error("GenerateWithInfo() with SnapToModule and ForceExactSize should fail")
+	}
+}
+
+func TestGenerateImage_ReserveCenter(t *testing.T) {
+	// Version 3 at 10px per module without a quiet zone
+	base := Options{Data: "https://example.com", Size: 290, Foreground: "black", Background: "white", Error: "H"}
+	plain, err := GenerateImage(base)
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+
+	opts := base
+	opts.ReserveCenter = 30
+	img, err := GenerateImage(opts)
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+	// The 87px square from (101,101) rounds out to modules 10-18, pixels 100-189
+	reserved := image.Rect(100, 100, 190, 190)
+	cleared := 0
+	for y := 0; y < 290; y++ {
+		for x := 0; x < 290; x++ {
+			got, _, _, _ := img.At(x, y).RGBA()
+			want, _, _, _ := plain.At(x, y).RGBA()
+			if image.Pt(x, y).In(reserved) {
+				if got != 0xffff {
+					t.Fatalf("reserved pixel (%d,%d) is not background", x, y)
+				}
+				if want != 0xffff {
+					cleared++
+				}
+			} else if got != want {
+				t.Fatalf("pixel (%d,%d) outside the reserved area changed", x, y)
+			}
+		}
+	}
+	if cleared == 0 {
+		t.Error("ReserveCenter should clear dark modules in the center")
+	}
+
+	for _, percent := range []float64{-5, 80, 150} {
+		opts.ReserveCenter = percent
+		if _, err := GenerateImage(opts); err == nil {
+			t.Errorf("GenerateImage() with ReserveCenter %v should fail", percent)
+		}
+	}
+}
+
+func TestGeneratePNG_LogoSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		logoSize float64
+		wantErr  bool
+	}{
+		{
+			name:     "default logo size",
+			logoSize: 0,
+			wantErr:  false, // Should use default 20.0
+		},
+		{
+			name:     "small logo",
+			logoSize: 10.0,
+			wantErr:  false,
+		},
+		{
+			name:     "large logo",
+			logoSize: 30.0,
+			wantErr:  false,
+		},
+		{
+			name:     "negative logo size",
+			logoSize: -5.0,
+			wantErr:  false, // Should use default
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{
+				Data:     "https://example.com",
+				Size:     500,
+				LogoSize: tt.logoSize,
+				// Note: LogoURL is empty, so logo won't be embedded
+				// This just tests that LogoSize doesn't cause errors
+			}
+			pngData, err := GeneratePNG(opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GeneratePNG() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && len(pngData) == 0 {
+				t.Error("GeneratePNG() returned empty PNG")
+			}
+		})
+	}
+}
+
+func TestGenerateWithInfo_LogoAuto(t *testing.T) {
+	server := newLogoServer(t, 50, 50, color.RGBA{R: 255, A: 255})
+	tests := []struct {
+		name  string
+		data  string
+		level string
+		want  float64
+	}{
+		// Version 1 at L: 21 * sqrt(0.07 / 2) modules of 29 in total
+		{"low", "test", "L", 21 * math.Sqrt(0.035) / 29 * 100},
+		// Version 1 at H: capped at 5 of 21 modules to clear the finders
+		{"highest clears finders", "test", "H", 5.0 / 29 * 100},
+		// Version 2 at M: 25 * sqrt(0.15 / 2) modules of 33 in total
+		{"medium", "https://example.com", "M", 25 * math.Sqrt(0.075) / 33 * 100},
+		// Version 3 at H: 29 * sqrt(0.30 / 2) modules of 37 in total
+		{"highest", "https://example.com", "H", 29 * math.Sqrt(0.15) / 37 * 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, info, err := GenerateWithInfo(Options{
+				Data:             tt.data,
+				Size:             300,
+				Error:            tt.level,
+				Border:           4,
+				LogoURL:          server.URL,
+				AllowedLogoHosts: testLogoHosts,
+				LogoSize:         50,
+				LogoAuto:         true,
+			})
+			if err != nil {
+				t.Fatalf("GenerateWithInfo() error = %v", err)
+			}
+			if math.Abs(info.LogoSize-tt.want) > 1e-9 {
+				t.Errorf("Info.LogoSize = %v, want %v", info.LogoSize, tt.want)
+			}
+		})
+	}
+
+	_, info, err := GenerateWithInfo(Options{Data: "test", LogoURL: server.URL, AllowedLogoHosts: testLogoHosts, LogoSize: 15})
+	if err != nil {
+		t.Fatalf("GenerateWithInfo() error = %v", err)
+	}
+	if info.LogoSize != 15 {
+		t.Errorf("Info.LogoSize without LogoAuto = %v, want 15", info.LogoSize)
+	}
+
+	if _, _, err := GenerateWithInfo(Options{Data: "test", LogoAuto: true, LogoNoResize: true}); err == nil {
+		t.Error("GenerateWithInfo() with LogoAuto and LogoNoResize should fail")
+	}
+	if _, err := New().RenderMatrix([][]bool{{true}}, RenderOptions{Options: Options{LogoAuto: true}}); err == nil {
+		t.Error("RenderMatrix() with LogoAuto should fail")
+	}
+}
+
+func TestGeneratePNG_LogoNoResize(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	server := newLogoServer(t, 40, 40, red)
+
+	pngData, err := GeneratePNG(Options{
+		Data:             "https://example.com",
+		Size:             300,
+		Foreground:       "black",
+		Background:       "white",
+		Error:            "H",
+		LogoURL:          server.URL,
+		AllowedLogoHosts: testLogoHosts,
+		LogoNoResize:     true,
+	})
+	if err != nil {
+		t.Fatalf("GeneratePNG() error = %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(pngData))
+	if err != nil {
+		t.Fatalf("GeneratePNG() returned invalid PNG: %v", err)
+	}
+
+	center := img.Bounds().Dx() / 2
+	for _, tc := range []struct {
+		x, y    int
+		wantRed bool
+	}{
+		{center - 20, center - 20, true},
+		{center + 19, center + 19, true},
+		{center - 21, center, false},
+		{center + 20, center, false},
+	} {
+		r, g, b, _ := img.At(tc.x, tc.y).RGBA()
+		isRed := r == 0xffff && g == 0 && b == 0
+		if isRed != tc.wantRed {
+			t.Errorf("pixel (%d,%d) red = %v, want %v", tc.x, tc.y, isRed, tc.wantRed)
+		}
+	}
+
+	large := newLogoServer(t, 400, 400, red)
+	_, err = GeneratePNG(Options{
+		Data:             "https://example.com",
+		Size:             300,
+		LogoURL:          large.URL,
+		AllowedLogoHosts: testLogoHosts,
+		LogoNoResize:     true,
+	})
+	if err == nil {
+		t.Error("GeneratePNG() with a logo larger than the code should fail")
+	}
+}
+
+func TestGeneratePNG_LogoOffset(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	server := newLogoServer(t, 40, 40, red)
+
+	tests := []struct {
+		name             string
+		offsetX, offsetY int
+		wantErr          bool
+	}{
+		{name: "right and down", offsetX: 30, offsetY: 15},
+		{name: "left and up", offsetX: -50, offsetY: -25},
+		{name: "to the edge", offsetX: 130},
+		{name: "past the right edge", offsetX: 131, wantErr: true},
+		{name: "past the top edge", offsetY: -131, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pngData, err := GeneratePNG(Options{
+				Data:             "https://example.com",
+				Size:             300,
+				Foreground:       "black",
+				Background:       "white",
+				Error:            "H",
+				LogoURL:          server.URL,
+				AllowedLogoHosts: testLogoHosts,
+				LogoNoResize:     true,
+				LogoOffsetX:      tt.offsetX,
+				LogoOffsetY:      tt.offsetY,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GeneratePNG() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			img, err := png.Decode(bytes.NewReader(pngData))
+			if err != nil {
+				t.Fatalf("GeneratePNG() returned invalid PNG: %v", err)
+			}
+
+			left := 130 + tt.offsetX
+			top := 130 + tt.offsetY
+			for _, tc := range []struct {
+				x, y    int
+				wantRed bool
+			}{
+				{left, top, true},
+				{left + 39, top + 39, true},
+				{left - 1, top + 20, false},
+				{left + 20, top + 40, false},
+			} {
+				if !image.Pt(tc.x, tc.y).In(img.Bounds()) {
+					continue
+				}
+				r, g, b, _ := img.At(tc.x, tc.y).RGBA()
+				isRed := r == 0xffff && g == 0 && b == 0
+				if isRed != tc.wantRed {
+					t.Errorf("pixel (%d,%d) red = %v, want %v", tc.x, tc.y, isRed, tc.wantRed)
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratePNG_LogoFinderOverlap(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	server := newLogoServer(t, 100, 100, red)
+
+	// Version 1 (21 modules) at 10px per module: finders and separators cover pixels 0-79 and
+	// 130-209, so a centered logo clears them up to 50px
+	tests := []struct {
+		name      string
+		logoSize  float64
+		strict    bool
+		wantErr   bool
+		wantWidth int
+	}{
+		{name: "small logo is kept", logoSize: 20, wantWidth: 42},
+		{name: "large logo is shrunk", logoSize: 80, wantWidth: 50},
+		{name: "large logo fails in strict mode", logoSize: 80, strict: true, wantErr: true},
+		{name: "small logo passes strict mode", logoSize: 20, strict: true, wantWidth: 42},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := GenerateImage(Options{
+				Data:             "test",
+				Size:             210,
+				Foreground:       "black",
+				Background:       "white",
+				Error:            "L",
+				LogoURL:          server.URL,
+				AllowedLogoHosts: testLogoHosts,
+				LogoSize:         tt.logoSize,
+				LogoStrict:       tt.strict,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateImage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			width := 0
+			for x := 0; x < img.Bounds().Dx(); x++ {
+				if color.RGBAModel.Convert(img.At(x, 105)).(color.RGBA) == red {
+					width++
+				}
+			}
+			if width != tt.wantWidth {
+				t.Errorf("logo width = %d, want %d", width, tt.wantWidth)
+			}
+		})
+	}
+}
+
+func TestGeneratePNG_LogoCutout(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	green := color.RGBA{G: 255, A: 255}
+	server := newLogoServer(t, 33, 33, red)
+
+	// Version 2 (25 modules) at 10px per module: the logo box 108-141 spans modules 10-14,
+	// so the cutout covers pixels 100-150
+	img, err := GenerateImage(Options{
+		Data:             "https://example.com",
+		Size:             250,
+		Foreground:       "black",
+		Background:       "white",
+		LogoURL:          server.URL,
+		AllowedLogoHosts: testLogoHosts,
+		LogoNoResize:     true,
+		LogoCutout:       true,
+		LogoBackground:   "rgb(0,255,0)",
+	})
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		x, y int
+		want func(color.RGBA) bool
+	}{
+		{"cutout corner", 100, 100, func(c color.RGBA) bool { return c == green }},
+		{"cutout far corner", 149, 149, func(c color.RGBA) bool { return c == green }},
+		{"logo", 120, 120, func(c color.RGBA) bool { return c == red }},
+		{"outside cutout", 99, 120, func(c color.RGBA) bool { return c != green }},
+		{"outside cutout far side", 150, 120, func(c color.RGBA) bool { return c != green }},
+	}
+	for _, tt := range tests {
+		got := color.RGBAModel.Convert(img.At(tt.x, tt.y)).(color.RGBA)
+		if !tt.want(got) {
+			t.Errorf("%s: unexpected pixel color %v at (%d,%d)", tt.name, got, tt.x, tt.y)
+		}
+	}
+}
+
+func TestGenerateImage_LogoOverGradient(t *testing.T) {
+	logoColor := color.NRGBA{G: 255, A: 128}
+	server := newLogoServer(t, 50, 50, logoColor)
+	base := Options{
+		Data:             "https://example.com",
+		Size:             300,
+		Foreground:       "black",
+		Background:       "white",
+		Border:           4,
+		GradientStart:    "rgb(255,0,0)",
+		GradientEnd:      "rgb(0,0,255)",
+		GradientEdgeFade: 0.5,
+		AllowedLogoHosts: testLogoHosts,
+	}
+	code, err := GenerateImage(base)
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+	// over composites the given layers bottom up onto the code pixel at (x, y)
+	over := func(x, y int, layers ...color.Color) color.RGBA {
+		px := image.NewRGBA(image.Rect(0, 0, 1, 1))
+		px.Set(0, 0, code.At(x, y))
+		for _, layer := range layers {
+			draw.Draw(px, px.Bounds(), image.NewUniform(layer), image.Point{}, draw.Over)
+		}
+		return px.RGBAAt(0, 0)
+	}
+	near := func(a, b color.RGBA) bool {
+		d := func(x, y uint8) bool { return max(x, y)-min(x, y) <= 1 }
+		return d(a.R, b.R) && d(a.G, b.G) && d(a.B, b.B) && d(a.A, b.A)
+	}
+
+	tests := []struct {
+		name string
+		opts func(*Options)
+		want func(x, y int) color.RGBA
+	}{
+		{
+			name: "logo over gradient",
+			want: func(x, y int) color.RGBA { return over(x, y, logoColor) },
+		},
+		{
+			name: "logo over translucent cutout",
+			opts: func(o *Options) { o.LogoCutout = true; o.LogoBackground = "rgba(255,255,0,128)" },
+			want: func(x, y int) color.RGBA {
+				return over(x, y, color.White, color.NRGBA{R: 255, G: 255, A: 128}, logoColor)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := base
+			opts.LogoURL = server.URL
+			if tt.opts != nil {
+				tt.opts(&opts)
+			}
+			img, err := GenerateImage(opts)
+			if err != nil {
+				t.Fatalf("GenerateImage() error = %v", err)
+			}
+			for _, p := range []image.Point{{150, 150}, {140, 145}, {160, 155}} {
+				got := color.RGBAModel.Convert(img.At(p.X, p.Y)).(color.RGBA)
+				if want := tt.want(p.X, p.Y); !near(got, want) {
+					t.Errorf("pixel %v = %v, want %v", p, got, want)
+				}
+			}
+			if got, want := color.RGBAModel.Convert(img.At(10, 10)), color.RGBAModel.Convert(code.At(10, 10)); got != want {
+				t.Errorf("pixel outside the logo = %v, want %v", got, want)
+			}
+		})
+	}
+
+	t.Run("module drawer composites like squares", func(t *testing.T) {
+		opts := base
+		opts.ModuleDrawer = SquareDrawer{}
+		img, err := GenerateImage(opts)
+		if err != nil {
+			t.Fatalf("GenerateImage() error = %v", err)
+		}
+		for y := 0; y < img.Bounds().Dy(); y++ {
+			for x := 0; x < img.Bounds().Dx(); x++ {
+				if got, want := color.RGBAModel.Convert(img.At(x, y)), color.RGBAModel.Convert(code.At(x, y)); got != want {
+					t.Fatalf("pixel (%d,%d) = %v, want %v", x, y, got, want)
+				}
+			}
+		}
+	})
+}
+
+func TestGeneratePNG_LogoStretch(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	// A wide logo only fills the box vertically when stretched
+	server := newLogoServer(t, 100, 20, red)
+
+	for _, stretch := range []bool{false, true} {
+		pngData, err := GeneratePNG(Options{
+			Data:             "https://example.com",
+			Size:             300,
+			Foreground:       "black",
+			Background:       "white",
+			Error:            "H",
+			LogoURL:          server.URL,
+			AllowedLogoHosts: testLogoHosts,
+			LogoSize:         20.0,
+			LogoStretch:      stretch,
+		})
+		if err != nil {
+			t.Fatalf("GeneratePNG() error = %v", err)
+		}
+		img, err := png.Decode(bytes.NewReader(pngData))
+		if err != nil {
+			t.Fatalf("GeneratePNG() returned invalid PNG: %v", err)
+		}
+
+		// The 60x60 logo box starts at (120,120); its bottom-left corner is only
+		// covered when the logo is stretched
+		r, g, b, _ := img.At(121, 178).RGBA()
+		isRed := r == 0xffff && g == 0 && b == 0
+		if isRed != stretch {
+			t.Errorf("LogoStretch=%v: bottom of logo box red = %v", stretch, isRed)
+		}
+	}
+}
+
+func TestGeneratePNG_LogoTint(t *testing.T) {
+	tests := []struct {
+		name string
+		logo color.RGBA
+		tint string
+		want color.RGBA
+	}{
+		{name: "no tint", logo: color.RGBA{G: 128, B: 255, A: 255}, want: color.RGBA{G: 128, B: 255, A: 255}},
+		{name: "black takes the tint", logo: color.RGBA{A: 255}, tint: "red", want: color.RGBA{R: 255, A: 255}},
+		{name: "white stays white", logo: color.RGBA{R: 255, G: 255, B: 255, A: 255}, tint: "red", want: color.RGBA{R: 255, G: 255, B: 255, A: 255}},
+		{name: "gray blends halfway", logo: color.RGBA{R: 128, G: 128, B: 128, A: 255}, tint: "red", want: color.RGBA{R: 255, G: 128, B: 128, A: 255}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newLogoServer(t, 60, 60, tt.logo)
+			img, err := GenerateImage(Options{
+				Data:             "https://example.com",
+				Size:             300,
+				Foreground:       "black",
+				Background:       "white",
+				Error:            "H",
+				LogoURL:          server.URL,
+				AllowedLogoHosts: testLogoHosts,
+				LogoTint:         tt.tint,
+			})
+			if err != nil {
+				t.Fatalf("GenerateImage() error = %v", err)
+			}
+			if got := color.RGBAModel.Convert(img.At(150, 150)).(color.RGBA); got != tt.want {
+				t.Errorf("logo center = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeneratePNG_LogoFormat(t *testing.T) {
+	logo := image.NewRGBA(image.Rect(0, 0, 60, 60))
+	draw.Draw(logo, logo.Bounds(), &image.Uniform{C: color.RGBA{R: 255, A: 255}}, image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	if err := bmp.Encode(&buf, logo); err != nil {
+		t.Fatalf("failed to encode logo: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(buf.Bytes())
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		format  string
+		wantErr string
+	}{
+		{format: ""},
+		{format: "bmp"},
+		{format: "BMP"},
+		{format: "png", wantErr: "failed to decode logo image"},
+		{format: "heic", wantErr: `unsupported logo format "heic"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			_, err := GenerateImage(Options{
+				Data:             "https://example.com",
+				Error:            "H",
+				LogoURL:          server.URL,
+				AllowedLogoHosts: testLogoHosts,
+				LogoFormat:       tt.format,
+			})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("GenerateImage() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("GenerateImage() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateImage_LogoSources(t *testing.T) {
+	encodeLogo := func(c color.Color) []byte {
+		logo := image.NewRGBA(image.Rect(0, 0, 60, 60))
+		draw.Draw(logo, logo.Bounds(), &image.Uniform{C: c}, image.Point{}, draw.Src)
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, logo); err != nil {
+			t.Fatalf("failed to encode logo: %v", err)
+		}
+		return buf.Bytes()
+	}
+	red, green, blue := color.RGBA{R: 255, A: 255}, color.RGBA{G: 255, A: 255}, color.RGBA{B: 255, A: 255}
+	path := filepath.Join(t.TempDir(), "logo.png")
+	if err := os.WriteFile(path, encodeLogo(green), 0o644); err != nil {
+		t.Fatalf("failed to write logo: %v", err)
+	}
+	server := newLogoServer(t, 60, 60, blue)
+
+	tests := []struct {
+		name    string
+		reader  bool
+		path    string
+		url     string
+		want    color.RGBA
+		wantErr string
+	}{
+		{name: "reader", reader: true, want: red},
+		{name: "path", path: path, want: green},
+		{name: "url", url: server.URL, want: blue},
+		{name: "reader over path and url", reader: true, path: path, url: server.URL, want: red},
+		{name: "path over url", path: path, url: server.URL, want: green},
+		{name: "missing path", path: filepath.Join(t.TempDir(), "missing.png"), wantErr: "failed to open logo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{
+				Data:             "https://example.com",
+				Size:             300,
+				Error:            "H",
+				LogoPath:         tt.path,
+				LogoURL:          tt.url,
+				AllowedLogoHosts: testLogoHosts,
+			}
+			if tt.reader {
+				opts.LogoReader = bytes.NewReader(encodeLogo(red))
+			}
+			img, err := GenerateImage(opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("GenerateImage() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateImage() error = %v", err)
+			}
+			if got := color.RGBAModel.Convert(img.At(150, 150)).(color.RGBA); got != tt.want {
+				t.Errorf("center pixel = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := GenerateImage(Options{Data: "https://example.com", LogoReader: strings.NewReader("not an image")}); err == nil ||
+		!strings.Contains(err.Error(), "failed to decode logo image") {
+		t.Errorf("GenerateImage() with undecodable reader error = %v", err)
+	}
+}
+
+func TestGeneratePNG_LogoDeterministic(t *testing.T) {
+	server := newLogoServer(t, 64, 32, color.RGBA{R: 200, G: 40, B: 90, A: 180})
+	opts := Options{
+		Data:             "https://example.com",
+		Size:             300,
+		Foreground:       "black",
+		Background:       "white",
+		GradientStart:    "rgb(255,0,0)",
+		GradientEnd:      "rgb(0,0,255)",
+		Error:            "H",
+		LogoURL:          server.URL,
+		AllowedLogoHosts: testLogoHosts,
+	}
+
+	const workers = 2
+	results := make([][]byte, workers)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = GeneratePNG(opts)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("GeneratePNG() call %d error = %v", i, err)
+		}
+	}
+	if !bytes.Equal(results[0], results[1]) {
+		t.Error("concurrent GeneratePNG() calls with the same logo produced different output")
+	}
+}
+
+func TestGeneratePNG_PreviewCheckerboard(t *testing.T) {
+	tests := []struct {
+		name    string
+		preview bool
+		want    color.RGBA
+	}{
+		{"transparent output", false, color.RGBA{}},
+		{"checkerboard preview", true, color.RGBA{R: 0xee, G: 0xee, B: 0xee, A: 0xff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pngData, err := GeneratePNG(Options{
+				Data:                "https://example.com",
+				Size:                300,
+				Foreground:          "black",
+				Background:          "rgba(255,255,255,0)",
+				Border:              4,
+				PreviewCheckerboard: tt.preview,
+			})
+			if err != nil {
+				t.Fatalf("GeneratePNG() error = %v", err)
+			}
+			img, err := png.Decode(bytes.NewReader(pngData))
+			if err != nil {
+				t.Fatalf("GeneratePNG() returned invalid PNG: %v", err)
+			}
+
+			// The corner lies in the quiet zone, i.e. on the transparent background
+			got := color.RGBAModel.Convert(img.At(0, 0)).(color.RGBA)
+			if got != tt.want {
+				t.Errorf("corner pixel = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateImage_FrameStyle(t *testing.T) {
+	base := Options{Data: "https://example.com", Size: 300, Foreground: "black", Background: "white"}
+	plain, err := GenerateImage(base)
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+	black := color.RGBA{A: 255}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+	// A 300px code gets a 10px band, a 10px margin and a 50px label strip
+	tests := []struct {
+		style  string
+		bounds image.Rectangle
+		pixels map[image.Point]color.RGBA
+	}{
+		{
+			style:  "scan-me-bottom",
+			bounds: image.Rect(0, 0, 340, 405),
+			pixels: map[image.Point]color.RGBA{
+				{0, 0}:     black,
+				{15, 15}:   white,
+				{170, 340}: black, // pointer tip
+				{150, 340}: white,
+				{0, 400}:   black,
+			},
+		},
+		{
+			style:  "rounded-badge",
+			bounds: image.Rect(0, 0, 340, 390),
+			pixels: map[image.Point]color.RGBA{
+				{0, 0}:     {},
+				{170, 0}:   black,
+				{170, 12}:  white,
+				{170, 388}: black,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			opts := base
+			opts.FrameStyle = tt.style
+			img, err := GenerateImage(opts)
+			if err != nil {
+				t.Fatalf("GenerateImage() error = %v", err)
+			}
+			if img.Bounds() != tt.bounds {
+				t.Fatalf("bounds = %v, want %v", img.Bounds(), tt.bounds)
+			}
+			if bounds, err := New().OutputBounds(opts); err != nil || bounds != tt.bounds {
+				t.Errorf("OutputBounds() = %v, %v, want %v", bounds, err, tt.bounds)
+			}
+			for p, want := range tt.pixels {
+				if got := color.RGBAModel.Convert(img.At(p.X, p.Y)).(color.RGBA); got != want {
+					t.Errorf("pixel %v = %v, want %v", p, got, want)
+				}
+			}
+			for y := 0; y < 300; y++ {
+				for x := 0; x < 300; x++ {
+					wr, _, _, _ := plain.At(x, y).RGBA()
+					gr, _, _, _ := img.At(x+20, y+20).RGBA()
+					if wr != gr {
+						t.Fatalf("code pixel (%d,%d) differs from the unframed code", x, y)
+					}
+				}
+			}
+			labelPixels := 0
+			for y := tt.bounds.Max.Y - 50; y < tt.bounds.Max.Y; y++ {
+				for x := 0; x < tt.bounds.Max.X; x++ {
+					if r, _, _, _ := img.At(x, y).RGBA(); r == 0xffff {
+						labelPixels++
+					}
+				}
+			}
+			if labelPixels == 0 {
+				t.Error("frame label should be drawn in the background color")
+			}
+		})
+	}
+
+	invalid := base
+	invalid.FrameStyle = "hexagon"
+	if _, err := GenerateImage(invalid); err == nil {
+		t.Error("GenerateImage() with an unknown frame style should fail")
+	}
+}
+
+func TestGenerateImage_BackgroundPanel(t *testing.T) {
+	// Version 1 with a 4 module border: 29 modules of 10x10 pixels and a 40px quiet zone
+	base := Options{
+		Data:            "test",
+		Size:            290,
+		Foreground:      "black",
+		Background:      "white",
+		Error:           "L",
+		Border:          4,
+		BackgroundPanel: true,
+		PanelColor:      "blue",
+	}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	black := color.RGBA{A: 255}
+
+	tests := []struct {
+		name string
+		opts func(*Options)
+		x, y int
+		want color.RGBA
+	}{
+		{name: "corner outside panel", x: 0, y: 0, want: white},
+		{name: "quiet zone", x: 20, y: 20, want: blue},
+		{name: "edge of quiet zone", x: 0, y: 145, want: blue},
+		{name: "light module", x: 55, y: 55, want: blue},
+		{name: "dark module", x: 45, y: 45, want: black},
+		{name: "explicit radius", opts: func(o *Options) { o.PanelRadius = 40 }, x: 5, y: 5, want: white},
+		{name: "caption strip keeps background", opts: func(o *Options) { o.Caption = "Scan me" }, x: 0, y: 300, want: white},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := base
+			if tt.opts != nil {
+				tt.opts(&opts)
+			}
+			img, err := GenerateImage(opts)
+			if err != nil {
+				t.Fatalf("GenerateImage() error = %v", err)
+			}
+			if got := color.RGBAModel.Convert(img.At(tt.x, tt.y)).(color.RGBA); got != tt.want {
+				t.Errorf("pixel (%d,%d) = %v, want %v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+
+	invalid := map[string]func(*Options){
+		"missing panel color":       func(o *Options) { o.PanelColor = "" },
+		"radius beyond quiet zone":  func(o *Options) { o.PanelRadius = 41 },
+		"negative radius":           func(o *Options) { o.PanelRadius = -1 },
+		"radius without quiet zone": func(o *Options) { o.Border = 0; o.PanelRadius = 1 },
+	}
+	for name, modify := range invalid {
+		opts := base
+		modify(&opts)
+		if _, err := GenerateImage(opts); err == nil {
+			t.Errorf("GenerateImage() with %s should fail", name)
+		}
+	}
+}
+
+func TestGeneratePNG_Caption(t *testing.T) {
+	opts := Options{
+		Data:          "https://example.com",
+		Size:          250,
+		Foreground:    "black",
+		Background:    "white",
+		Border:        4,
+		Caption:       "Scan me",
+		CaptionColor:  "red",
+		CaptionHeight: 50,
+	}
+	pngData, err := GeneratePNG(opts)
+	if err != nil {
+		t.Fatalf("GeneratePNG() error = %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(pngData))
+	if err != nil {
+		t.Fatalf("GeneratePNG() returned invalid PNG: %v", err)
+	}
+
+	if got, want := img.Bounds().Dy(), img.Bounds().Dx()+50; got != want {
+		t.Fatalf("image height = %d, want %d", got, want)
+	}
+
+	red := color.RGBA{R: 255, A: 255}
+	codeHeight := img.Bounds().Dx()
+	var captionPixels int
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			if color.RGBAModel.Convert(img.At(x, y)).(color.RGBA) != red {
+				continue
+			}
+			if y < codeHeight {
+				t.Fatalf("caption pixel (%d,%d) drawn over the code", x, y)
+			}
+			captionPixels++
+		}
+	}
+	if captionPixels == 0 {
+		t.Error("caption text was not drawn")
+	}
+}
+
+func TestGenerateImage_ShowText(t *testing.T) {
+	long := "https://example.com/" + strings.Repeat("a", 200)
+	tests := []struct {
+		name    string
+		data    string
+		caption string
+	}{
+		{"short payload", "https://example.com", ""},
+		{"long payload", long, ""},
+		{"explicit caption wins", "https://example.com", "Scan me"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{
+				Data:       tt.data,
+				Size:       250,
+				Foreground: "black",
+				Background: "white",
+				Caption:    tt.caption,
+				ShowText:   true,
+			}
+			img, err := GenerateImage(opts)
+			if err != nil {
+				t.Fatalf("GenerateImage() error = %v", err)
+			}
+			bounds, err := New().OutputBounds(opts)
+			if err != nil {
+				t.Fatalf("OutputBounds() error = %v", err)
+			}
+			if img.Bounds() != bounds {
+				t.Fatalf("image bounds = %v, OutputBounds() = %v", img.Bounds(), bounds)
+			}
+			if got, want := img.Bounds().Dy(), img.Bounds().Dx()+defaultCaptionHeight; got != want {
+				t.Fatalf("image height = %d, want %d", got, want)
+			}
+
+			// Text must stay inside the padded strip, away from the left and right edges
+			codeHeight := img.Bounds().Dx()
+			var textPixels int
+			for y := codeHeight; y < img.Bounds().Dy(); y++ {
+				for x := 0; x < img.Bounds().Dx(); x++ {
+					if r, _, _, _ := img.At(x, y).RGBA(); r < 0x8000 {
+						if x < captionPadding || x >= img.Bounds().Dx()-captionPadding {
+							t.Fatalf("caption pixel (%d,%d) outside the padded strip", x, y)
+						}
+						textPixels++
+					}
+				}
+			}
+			if textPixels == 0 {
+				t.Error("payload text was not drawn")
+			}
+		})
+	}
+}
+
+func TestEllipsize(t *testing.T) {
+	face := basicfont.Face7x13
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  string
+	}{
+		{"fits", "abc", 21, "abc"},
+		{"shortened", "abcdefgh", 42, "abc..."},
+		{"nothing fits", "abcdefgh", 7, "..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ellipsize(tt.text, face, tt.width); got != tt.want {
+				t.Errorf("ellipsize(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeneratePNG_CaptionFont(t *testing.T) {
+	tests := []struct {
+		name    string
+		font    []byte
+		wantErr bool
+	}{
+		{"default font", nil, false},
+		{"truetype font", goregular.TTF, false},
+		{"invalid font", []byte("not a font"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pngData, err := GeneratePNG(Options{
+				Data:        "https://example.com",
+				Size:        250,
+				Foreground:  "black",
+				Background:  "white",
+				Caption:     "Scan me",
+				CaptionFont: tt.font,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GeneratePNG() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			img, err := png.Decode(bytes.NewReader(pngData))
+			if err != nil {
+				t.Fatalf("GeneratePNG() returned invalid PNG: %v", err)
+			}
+
+			codeHeight := img.Bounds().Dx()
+			var textPixels int
+			for y := codeHeight; y < img.Bounds().Dy(); y++ {
+				for x := 0; x < img.Bounds().Dx(); x++ {
+					if r, _, _, _ := img.At(x, y).RGBA(); r < 0x8000 {
+						textPixels++
+					}
+				}
+			}
+			if textPixels == 0 {
+				t.Error("caption text was not drawn")
+			}
+		})
+	}
+}
+
+func TestGeneratePNG_Clip(t *testing.T) {
+	opts := Options{
+		Data:       "https://example.com",
+		Size:       250,
+		Foreground: "black",
+		Background: "white",
+	}
+	fullData, err := GeneratePNG(opts)
+	if err != nil {
+		t.Fatalf("GeneratePNG() error = %v", err)
+	}
+	full, err := png.Decode(bytes.NewReader(fullData))
+	if err != nil {
+		t.Fatalf("GeneratePNG() returned invalid PNG: %v", err)
+	}
+
+	opts.Clip = image.Rect(0, 100, 250, 150)
+	clippedData, err := GeneratePNG(opts)
+	if err != nil {
+		t.Fatalf("GeneratePNG() with Clip error = %v", err)
+	}
+	clipped, err := png.Decode(bytes.NewReader(clippedData))
+	if err != nil {
+		t.Fatalf("GeneratePNG() with Clip returned invalid PNG: %v", err)
+	}
+
+	if clipped.Bounds() != image.Rect(0, 0, 250, 50) {
+		t.Fatalf("clipped bounds = %v, want %v", clipped.Bounds(), image.Rect(0, 0, 250, 50))
+	}
+	for y := 0; y < 50; y++ {
+		for x := 0; x < 250; x++ {
+			wr, wg, wb, wa := full.At(x, y+100).RGBA()
+			gr, gg, gb, ga := clipped.At(x, y).RGBA()
+			if wr != gr || wg != gg || wb != gb || wa != ga {
+				t.Fatalf("clipped pixel (%d,%d) differs from the full image", x, y)
+			}
+		}
+	}
+
+	opts.Clip = image.Rect(300, 300, 400, 400)
+	if _, err := GeneratePNG(opts); err == nil {
+		t.Error("GeneratePNG() with Clip outside the image should fail")
+	}
+}
+
+func TestGeneratePNG_AspectRatio(t *testing.T) {
+	tests := []struct {
+		name       string
+		ratio      string
+		wantWidth  int
+		wantHeight int
+		wantErr    bool
+	}{
+		{"landscape", "16:9", 320, 180, false},
+		{"portrait", "9:16", 180, 320, false},
+		{"square", "1:1", 180, 180, false},
+		{"missing height", "16", 0, 0, true},
+		{"zero", "0:9", 0, 0, true},
+		{"garbage", "wide", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pngData, err := GeneratePNG(Options{
+				Data:           "https://example.com",
+				Size:           180,
+				Foreground:     "black",
+				Background:     "white",
+				AspectRatio:    tt.ratio,
+				LetterboxColor: "blue",
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GeneratePNG() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			img, err := png.Decode(bytes.NewReader(pngData))
+			if err != nil {
+				t.Fatalf("GeneratePNG() returned invalid PNG: %v", err)
+			}
+			if img.Bounds().Dx() != tt.wantWidth || img.Bounds().Dy() != tt.wantHeight {
+				t.Fatalf("image size = %dx%d, want %dx%d", img.Bounds().Dx(), img.Bounds().Dy(), tt.wantWidth, tt.wantHeight)
+			}
+
+			blue := color.RGBA{B: 255, A: 255}
+			black := color.RGBA{A: 255}
+			left := (tt.wantWidth - 180) / 2
+			top := (tt.wantHeight - 180) / 2
+			if left > 0 || top > 0 {
+				if got := color.RGBAModel.Convert(img.At(0, 0)).(color.RGBA); got != blue {
+					t.Errorf("letterbox bar = %v, want blue", got)
+				}
+			}
+			// The finder pattern corner is at the offset of the centered code
+			if got := color.RGBAModel.Convert(img.At(left, top)).(color.RGBA); got != black {
+				t.Errorf("code corner = %v, want black", got)
+			}
+		})
+	}
+}
+
+func TestGeneratePNG_ForceExactSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		border int
+		logo   bool
+	}{
+		{name: "no border", size: 256},
+		{name: "default quiet zone", size: 256, border: 4},
+		{name: "large border", size: 256, border: 20},
+		{name: "smaller than symbol", size: 20, border: 4},
+		{name: "with logo", size: 300, border: 12, logo: true},
+	}
+	server := newLogoServer(t, 40, 40, color.RGBA{R: 255, A: 255})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{
+				Data:           "https://example.com",
+				Size:           tt.size,
+				Border:         tt.border,
+				ForceExactSize: true,
+			}
+			if tt.logo {
+				opts.LogoURL = server.URL
+				opts.AllowedLogoHosts = testLogoHosts
+				opts.LogoCutout = true
+			}
+			pngData, err := GeneratePNG(opts)
+			if err != nil {
+				t.Fatalf("GeneratePNG() error = %v", err)
+			}
+			img, err := png.Decode(bytes.NewReader(pngData))
+			if err != nil {
+				t.Fatalf("GeneratePNG() returned invalid PNG: %v", err)
+			}
+			if got := img.Bounds().Size(); got != image.Pt(tt.size, tt.size) {
+				t.Errorf("image size = %v, want %dx%d", got, tt.size, tt.size)
+			}
+		})
+	}
+}
+
+func TestGeneratePNG_CropMarks(t *testing.T) {
+	// A 300px code gets a 30px margin; marks start 7px away from the image edges
+	img, err := GenerateImage(Options{
+		Data:          "https://example.com",
+		Size:          300,
+		Foreground:    "black",
+		Background:    "white",
+		CropMarks:     true,
+		CropMarkColor: "red",
+	})
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+	if got, want := img.Bounds(), image.Rect(0, 0, 360, 360); got != want {
+		t.Fatalf("bounds = %v, want %v", got, want)
+	}
+
+	red := color.RGBA{R: 255, A: 255}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	tests := []struct {
+		name string
+		x, y int
+		want color.RGBA
+	}{
+		{"top left horizontal mark", 0, 30, red},
+		{"top left vertical mark", 30, 22, red},
+		{"gap before the image", 25, 30, white},
+		{"bottom right horizontal mark", 359, 329, red},
+		{"bottom right vertical mark", 329, 359, red},
+		{"margin corner", 5, 5, white},
+		{"code corner", 30, 30, color.RGBA{A: 255}},
+	}
+	for _, tt := range tests {
+		got := color.RGBAModel.Convert(img.At(tt.x, tt.y)).(color.RGBA)
+		if got != tt.want {
+			t.Errorf("%s: pixel (%d,%d) = %v, want %v", tt.name, tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateImage_ColorModel(t *testing.T) {
+	base := Options{Data: "https://example.com", Size: 250, Foreground: "black", Background: "white"}
+	gradient := base
+	gradient.GradientStart, gradient.GradientEnd, gradient.GradientType = "red", "blue", "radial"
+	fast := base
+	fast.Fast = true
+
+	tests := []struct {
+		name     string
+		opts     Options
+		model    string
+		wantType string
+	}{
+		{name: "rgba", opts: base, model: "rgba", wantType: "*image.RGBA"},
+		{name: "nrgba", opts: base, model: "nrgba", wantType: "*image.NRGBA"},
+		{name: "gray", opts: base, model: "gray", wantType: "*image.Gray"},
+		{name: "paletted", opts: base, model: "paletted", wantType: "*image.Paletted"},
+		{name: "paletted gradient", opts: gradient, model: "paletted", wantType: "*image.Paletted"},
+		{name: "gray fast", opts: fast, model: "gray", wantType: "*image.Gray"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.ColorModel = tt.model
+			img, err := GenerateImage(opts)
+			if err != nil {
+				t.Fatalf("GenerateImage() error = %v", err)
+			}
+			if got := fmt.Sprintf("%T", img); got != tt.wantType {
+				t.Errorf("image type = %s, want %s", got, tt.wantType)
+			}
+			if r, _, _, _ := img.At(5, 5).RGBA(); r != 0 {
+				t.Errorf("finder pixel red = %d, want 0", r)
+			}
+		})
+	}
+
+	gray := base
+	gray.ColorModel = "gray"
+	pngData, err := GeneratePNG(gray)
+	if err != nil {
+		t.Fatalf("GeneratePNG() error = %v", err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(pngData))
+	if err != nil {
+		t.Fatalf("GeneratePNG() returned invalid PNG: %v", err)
+	}
+	if _, ok := decoded.(*image.Gray); !ok {
+		t.Errorf("decoded PNG type = %T, want *image.Gray", decoded)
+	}
+
+	invalid := base
+	invalid.ColorModel = "cmyk"
+	if _, err := GenerateImage(invalid); err == nil {
+		t.Error("GenerateImage() with an unknown color model should fail")
+	}
+}
+
+func TestGeneratePNG_MonochromeThreshold(t *testing.T) {
+	tests := []struct {
+		name       string
+		foreground string
+		threshold  uint8
+		wantBlack  bool
+	}{
+		{name: "dark gray at default threshold", foreground: "rgb(100,100,100)", wantBlack: true},
+		{name: "dark gray at low threshold", foreground: "rgb(100,100,100)", threshold: 90, wantBlack: false},
+		{name: "light gray at default threshold", foreground: "rgb(200,200,200)", wantBlack: false},
+		{name: "light gray at high threshold", foreground: "rgb(200,200,200)", threshold: 220, wantBlack: true},
+		{name: "translucent black over paper", foreground: "rgba(0,0,0,64)", wantBlack: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pngData, err := GeneratePNG(Options{
+				Data:                "https://example.com",
+				Size:                250,
+				Foreground:          tt.foreground,
+				Background:          "white",
+				ColorModel:          "monochrome",
+				MonochromeThreshold: tt.threshold,
+			})
+			if err != nil {
+				t.Fatalf("GeneratePNG() error = %v", err)
+			}
+			img, err := png.Decode(bytes.NewReader(pngData))
+			if err != nil {
+				t.Fatalf("GeneratePNG() returned invalid PNG: %v", err)
+			}
+			paletted, ok := img.(*image.Paletted)
+			if !ok || len(paletted.Palette) != 2 {
+				t.Fatalf("decoded PNG = %T, want a two color paletted image", img)
+			}
+			// Module (0,0) is the dark corner of the top left finder
+			r, _, _, _ := img.At(5, 5).RGBA()
+			if isBlack := r == 0; isBlack != tt.wantBlack {
+				t.Errorf("finder pixel black = %v, want %v", isBlack, tt.wantBlack)
+			}
+		})
+	}
+}
+
+func TestGeneratePNG_Fast(t *testing.T) {
+	opts := Options{
+		Data:       "https://example.com",
+		Size:       300,
+		Foreground: "black",
+		Background: "white",
+	}
+	want, err := GeneratePNG(opts)
+	if err != nil {
+		t.Fatalf("GeneratePNG() error = %v", err)
+	}
+
+	opts.Fast = true
+	opts.GradientStart = "rgb(255,0,0)"
+	opts.GradientEnd = "rgb(0,0,255)"
+	got, err := GeneratePNG(opts)
+	if err != nil {
+		t.Fatalf("GeneratePNG() with Fast error = %v", err)
+	}
+
+	wantImg, err := png.Decode(bytes.NewReader(want))
+	if err != nil {
+		t.Fatalf("invalid PNG: %v", err)
+	}
+	gotImg, err := png.Decode(bytes.NewReader(got))
+	if err != nil {
+		t.Fatalf("Fast returned invalid PNG: %v", err)
+	}
+	if gotImg.Bounds() != wantImg.Bounds() {
+		t.Fatalf("Fast bounds = %v, want %v", gotImg.Bounds(), wantImg.Bounds())
+	}
+	for y := 0; y < wantImg.Bounds().Dy(); y++ {
+		for x := 0; x < wantImg.Bounds().Dx(); x++ {
+			wr, wg, wb, wa := wantImg.At(x, y).RGBA()
+			gr, gg, gb, ga := gotImg.At(x, y).RGBA()
+			if wr != gr || wg != gg || wb != gb || wa != ga {
+				t.Fatalf("Fast pixel (%d,%d) differs from the default pipeline", x, y)
+			}
+		}
+	}
+}
+
+func TestGenerator_GeneratePNG(t *testing.T) {
+	generator := New()
+	if generator == nil {
+		t.Fatal("New() returned nil")
+	}
+
+	opts := Options{
+		Data: "https://example.com",
+		Size: 300,
+	}
+
+	pngData, err := generator.GeneratePNG(opts)
+	if err != nil {
+		t.Errorf("Generator.GeneratePNG() error = %v", err)
+		return
+	}
+
+	if len(pngData) == 0 {
+		t.Error("Generator.GeneratePNG() returned empty PNG")
+	}
+
+	// Verify it's a valid PNG
+	_, err = png.Decode(bytes.NewReader(pngData))
+	if err != nil {
+		t.Errorf("Generator.GeneratePNG() returned invalid PNG: %v", err)
+	}
+}
+
+func TestGeneratePNGReader(t *testing.T) {
+	opts := Options{Data: "https://example.com", Size: 200}
+	want, err := GeneratePNG(opts)
+	if err != nil {
+		t.Fatalf("GeneratePNG() error = %v", err)
+	}
+
+	r, n, err := GeneratePNGReader(opts)
+	if err != nil {
+		t.Fatalf("GeneratePNGReader() error = %v", err)
+	}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading PNG failed: %v", err)
+	}
+	if n != len(got) {
+		t.Errorf("GeneratePNGReader() length = %d, read %d bytes", n, len(got))
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("GeneratePNGReader() bytes differ from GeneratePNG()")
+	}
+
+	if _, _, err := GeneratePNGReader(Options{}); err == nil {
+		t.Error("GeneratePNGReader() without data should fail")
+	}
+}
+
+func TestGeneratePNGTo(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"plain", Options{Data: "https://example.com", Size: 200}},
+		{"metadata", Options{Data: "https://example.com", Size: 200, Metadata: map[string]string{"Title": "Example"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := GeneratePNG(tt.opts)
+			if err != nil {
+				t.Fatalf("GeneratePNG() error = %v", err)
+			}
+
+			generator := New()
+			var out bytes.Buffer
+			if err := generator.GeneratePNGTo(&out, tt.opts); err != nil {
+				t.Fatalf("GeneratePNGTo() error = %v", err)
+			}
+			if !bytes.Equal(out.Bytes(), want) {
+				t.Error("GeneratePNGTo() bytes differ from GeneratePNG()")
+			}
+			if m := generator.Metrics(); m.CodesGenerated != 1 || m.BytesOut != uint64(out.Len()) {
+				t.Errorf("Metrics() = %+v, want 1 code and %d bytes out", m, out.Len())
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		if err := GeneratePNGTo(nil, Options{Data: "https://example.com"}); err == nil {
+			t.Error("GeneratePNGTo() with a nil writer should fail")
+		}
+		if err := GeneratePNGTo(io.Discard, Options{}); err == nil {
+			t.Error("GeneratePNGTo() without data should fail")
+		}
+		if err := GeneratePNGTo(failingWriter{}, Options{Data: "https://example.com"}); err == nil {
+			t.Error("GeneratePNGTo() should report write errors")
+		}
+	})
+}
+
+// failingWriter is an io.Writer that always fails
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestGenerator_Defaults(t *testing.T) {
+	generator := New()
+	generator.Defaults = Options{
+		Size:       200,
+		Foreground: "red",
+		Background: "white",
+		Error:      "H",
+	}
+
+	tests := []struct {
+		name       string
+		opts       Options
+		wantSize   int
+		wantCorner color.RGBA
+	}{
+		{
+			name:       "defaults fill unset fields",
+			opts:       Options{Data: "https://example.com"},
+			wantSize:   200,
+			wantCorner: color.RGBA{R: 255, A: 255},
+		},
+		{
+			name:       "call fields win",
+			opts:       Options{Data: "https://example.com", Size: 150, Foreground: "blue"},
+			wantSize:   150,
+			wantCorner: color.RGBA{B: 255, A: 255},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := generator.GenerateImage(tt.opts)
+			if err != nil {
+				t.Fatalf("GenerateImage() error = %v", err)
+			}
+			if got := img.Bounds().Dx(); got != tt.wantSize {
+				t.Errorf("image size = %d, want %d", got, tt.wantSize)
+			}
+			if got := color.RGBAModel.Convert(img.At(0, 0)).(color.RGBA); got != tt.wantCorner {
+				t.Errorf("corner color = %v, want %v", got, tt.wantCorner)
+			}
+			bounds, err := generator.OutputBounds(tt.opts)
+			if err != nil {
+				t.Fatalf("OutputBounds() error = %v", err)
+			}
+			if bounds.Dx() != tt.wantSize {
+				t.Errorf("OutputBounds() width = %d, want %d", bounds.Dx(), tt.wantSize)
+			}
+		})
+	}
+
+	_, info, err := generator.GenerateWithInfo(Options{Data: "https://example.com"})
+	if err != nil {
+		t.Fatalf("GenerateWithInfo() error = %v", err)
+	}
+	if info.Version != 3 {
+		t.Errorf("version = %d, want 3 from the default error level H", info.Version)
+	}
+}
+
+func TestGenerator_DefaultsLogoReader(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	logo := image.NewRGBA(image.Rect(0, 0, 60, 60))
+	draw.Draw(logo, logo.Bounds(), &image.Uniform{C: red}, image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, logo); err != nil {
+		t.Fatalf("failed to encode logo: %v", err)
+	}
+	generator := New()
+	generator.Defaults = Options{Size: 300, Error: "H", LogoReader: bytes.NewReader(buf.Bytes())}
+
+	for i := 0; i < 2; i++ {
+		if _, err := generator.GenerateImage(Options{Data: "https://example.com"}); err == nil ||
+			!strings.Contains(err.Error(), "defaults cannot set a logo reader") {
+			t.Errorf("call %d: GenerateImage() with a default logo reader error = %v", i+1, err)
+		}
+	}
+
+	generator.Defaults.LogoReader = nil
+	for i := 0; i < 2; i++ {
+		img, err := generator.GenerateImage(Options{Data: "https://example.com", LogoReader: bytes.NewReader(buf.Bytes())})
+		if err != nil {
+			t.Fatalf("call %d: GenerateImage() error = %v", i+1, err)
+		}
+		if got := color.RGBAModel.Convert(img.At(150, 150)).(color.RGBA); got != red {
+			t.Errorf("call %d: center pixel = %v, want the logo color %v", i+1, got, red)
+		}
+	}
+}
+
+func TestGenerator_OnEvent(t *testing.T) {
+	server := newLogoServer(t, 20, 20, color.RGBA{R: 255, A: 255})
+
+	var events []string
+	generator := New()
+	generator.OnEvent = func(event string, meta map[string]any) {
+		events = append(events, event)
+		if _, ok := meta["elapsed"].(time.Duration); !ok {
+			t.Errorf("event %s: missing elapsed duration in %v", event, meta)
+		}
+		switch event {
+		case EventEncoded:
+			if meta["version"] != 2 {
+				t.Errorf("encoded event version = %v, want 2", meta["version"])
+			}
+		case EventLogoFetched:
+			if meta["url"] != server.URL {
+				t.Errorf("logo event url = %v, want %s", meta["url"], server.URL)
+			}
+		case EventDone:
+			if n, _ := meta["bytes"].(int); n <= 0 {
+				t.Errorf("done event bytes = %v", meta["bytes"])
+			}
+		}
+	}
+
+	_, err := generator.GeneratePNG(Options{
+		Data:             "https://example.com",
+		Size:             300,
+		LogoURL:          server.URL,
+		AllowedLogoHosts: testLogoHosts,
+	})
+	if err != nil {
+		t.Fatalf("GeneratePNG() error = %v", err)
+	}
+
+	want := []string{EventStart, EventEncoded, EventLogoFetched, EventDone}
+	if len(events) != len(want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Fatalf("events = %v, want %v", events, want)
+		}
+	}
+}
+
+func TestGenerator_Metrics(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	generator := New()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := generator.GeneratePNG(Options{Data: "https://example.com"}); err != nil {
+				t.Errorf("GeneratePNG() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := generator.GeneratePNG(Options{Data: "https://example.com", LogoURL: server.URL, AllowedLogoHosts: testLogoHosts}); err == nil {
+		t.Fatal("GeneratePNG() with a missing logo should fail")
+	}
+	// Compositing errors are not fetch failures
+	logo := newLogoServer(t, 20, 20, color.RGBA{R: 255, A: 255})
+	if _, err := generator.GeneratePNG(Options{Data: "https://example.com", LogoURL: logo.URL, AllowedLogoHosts: testLogoHosts, LogoOffsetX: 10000}); err == nil {
+		t.Fatal("GeneratePNG() with a logo outside the code should fail")
+	}
+	if _, err := generator.GeneratePNG(Options{}); err == nil {
+		t.Fatal("GeneratePNG() with empty data should fail")
+	}
+
+	metrics := generator.Metrics()
+	if metrics.CodesGenerated != 4 {
+		t.Errorf("CodesGenerated = %d, want 4", metrics.CodesGenerated)
+	}
+	if metrics.LogoFetchFailures != 1 {
+		t.Errorf("LogoFetchFailures = %d, want 1", metrics.LogoFetchFailures)
+	}
+	if metrics.BytesOut == 0 {
+		t.Error("BytesOut = 0, want the total size of generated images")
+	}
+}
+
+func TestGenerator_OutputBounds(t *testing.T) {
+	generator := New()
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"default", Options{Data: "https://example.com"}},
+		{"no border", Options{Data: "https://example.com", Size: 256}},
+		{"small border", Options{Data: "https://example.com", Size: 256, Border: 2}},
+		{"large border", Options{Data: "https://example.com", Size: 256, Border: 20}},
+		{"smaller than symbol", Options{Data: "https://example.com", Size: 10, Border: 4}},
+		{"caption", Options{Data: "https://example.com", Size: 256, Caption: "Scan me"}},
+		{"caption height", Options{Data: "https://example.com", Size: 256, Caption: "Scan me", CaptionHeight: 64}},
+		{"aspect ratio", Options{Data: "https://example.com", Size: 256, AspectRatio: "16:9"}},
+		{"portrait aspect ratio", Options{Data: "https://example.com", Size: 256, AspectRatio: "9:16", Caption: "Scan me"}},
+		{"clip", Options{Data: "https://example.com", Size: 256, Clip: image.Rect(0, 100, 256, 140)}},
+		{"clip past edge", Options{Data: "https://example.com", Size: 256, Clip: image.Rect(200, 200, 400, 400)}},
+		{"exact size", Options{Data: "https://example.com", Size: 256, Border: 20, ForceExactSize: true}},
+		{"crop marks", Options{Data: "https://example.com", Size: 256, CropMarks: true, AspectRatio: "4:3"}},
+		{"fast ignores caption", Options{Data: "https://example.com", Size: 300, Fast: true, Caption: "Scan me"}},
+		{"fast ignores frame and clip", Options{Data: "https://example.com", Size: 300, Fast: true, FrameStyle: "scan-me-bottom", Clip: image.Rect(0, 0, 100, 100)}},
+		{"fast ignores layout", Options{Data: "https://example.com", Size: 300, Fast: true, AspectRatio: "16:9", CropMarks: true}},
+		{"fast exact size smaller than symbol", Options{Data: "https://example.com", Size: 10, Border: 4, Fast: true, ForceExactSize: true}},
+		{"fast exact size", Options{Data: "https://example.com", Size: 256, Border: 20, Fast: true, ForceExactSize: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bounds, err := generator.OutputBounds(tt.opts)
+			if err != nil {
+				t.Fatalf("OutputBounds() error = %v", err)
+			}
+			pngData, err := generator.GeneratePNG(tt.opts)
+			if err != nil {
+				t.Fatalf("GeneratePNG() error = %v", err)
+			}
+			img, err := png.Decode(bytes.NewReader(pngData))
+			if err != nil {
+				t.Fatalf("GeneratePNG() returned invalid PNG: %v", err)
+			}
+			if bounds != img.Bounds() {
+				t.Errorf("OutputBounds() = %v, rendered image bounds = %v", bounds, img.Bounds())
+			}
+		})
+	}
+
+	if _, err := generator.OutputBounds(Options{}); err == nil {
+		t.Error("OutputBounds() with empty data should fail")
+	}
+}
+
+func TestGenerateFrames(t *testing.T) {
+	colors := []string{"red", "green", "blue"}
+	frames, err := GenerateFrames(Options{
+		Data:       "https://example.com",
+		Size:       250,
+		Background: "white",
+	}, len(colors), func(i int, o *Options) {
+		o.Foreground = colors[i]
+	})
+	if err != nil {
+		t.Fatalf("GenerateFrames() error = %v", err)
+	}
+	if len(frames) != len(colors) {
+		t.Fatalf("GenerateFrames() returned %d frames, want %d", len(frames), len(colors))
+	}
+
+	for i, frame := range frames {
+		// The top left corner is part of a finder pattern and thus drawn in the foreground color
+		got := color.RGBAModel.Convert(frame.At(0, 0)).(color.RGBA)
+		want := color.RGBAModel.Convert(parseColor(colors[i])).(color.RGBA)
+		if got != want {
+			t.Errorf("frame %d corner = %v, want %v", i, got, want)
+		}
+	}
+
+	if _, err := GenerateFrames(Options{Data: "test"}, 0, nil); err == nil {
+		t.Error("GenerateFrames() with zero frames should fail")
+	}
+	if _, err := GenerateFrames(Options{Data: "test"}, 2, func(i int, o *Options) {
+		if i == 1 {
+			o.Data = ""
+		}
+	}); err == nil {
+		t.Error("GenerateFrames() should fail when a frame is invalid")
+	}
+}
+
+func TestRenderMatrix(t *testing.T) {
+	opts := Options{
+		Data:           "https://example.com",
+		Size:           290,
+		Foreground:     "black",
+		Background:     "white",
+		Border:         quietZoneModules,
+		AlignmentColor: "red",
+		EyeBallShape:   "circle",
+	}
+	want, err := GenerateImage(opts)
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+
+	qr, err := qrcode.New(opts.Data, qrcode.Medium)
+	if err != nil {
+		t.Fatalf("qrcode.New() error = %v", err)
+	}
+	qr.DisableBorder = true
+	got, err := RenderMatrix(qr.Bitmap(), RenderOptions{Options: opts, QuietZone: quietZoneModules})
+	if err != nil {
+		t.Fatalf("RenderMatrix() error = %v", err)
+	}
+
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("RenderMatrix() bounds = %v, want %v", got.Bounds(), want.Bounds())
+	}
+	for y := 0; y < want.Bounds().Dy(); y++ {
+		for x := 0; x < want.Bounds().Dx(); x++ {
+			wr, wg, wb, wa := want.At(x, y).RGBA()
+			gr, gg, gb, ga := got.At(x, y).RGBA()
+			if wr != gr || wg != gg || wb != gb || wa != ga {
+				t.Fatalf("RenderMatrix() pixel (%d,%d) differs from GenerateImage()", x, y)
+			}
+		}
+	}
+
+	tests := []struct {
+		name   string
+		matrix [][]bool
+		opts   RenderOptions
+	}{
+		{
+			name: "empty matrix",
+		},
+		{
+			name:   "non-square matrix",
+			matrix: [][]bool{{true, false}, {true}},
+		},
+		{
+			name:   "eye ball shape without QR structure",
+			matrix: [][]bool{{true, false}, {false, true}},
+			opts:   RenderOptions{Options: Options{EyeBallShape: "circle"}},
+		},
+		{
+			name:   "unknown eye ball shape",
+			matrix: [][]bool{{true, false}, {false, true}},
+			opts:   RenderOptions{Options: Options{EyeBallShape: "cirle"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := RenderMatrix(tt.matrix, tt.opts); err == nil {
+				t.Error("RenderMatrix() should fail")
+			}
+		})
+	}
+}
+
+func TestGenerator_MaxPixels(t *testing.T) {
+	base := Options{Data: "https://example.com", Size: 250, Border: 4}
+	tests := []struct {
+		name      string
+		maxPixels int
+		opts      func(*Options)
+		wantErr   bool
+	}{
+		{name: "at the limit", maxPixels: 250 * 250},
+		{name: "over the limit", maxPixels: 250*250 - 1, wantErr: true},
+		{name: "caption counts", maxPixels: 250 * 250, opts: func(o *Options) { o.Caption = "Scan me" }, wantErr: true},
+		{name: "banner counts", maxPixels: 250 * 250, opts: func(o *Options) { o.Banner = &Banner{Text: "My App"} }, wantErr: true},
+		{name: "fast path", maxPixels: 100 * 100, opts: func(o *Options) { o.Fast = true }, wantErr: true},
+		{name: "default limit", opts: func(o *Options) { o.Size = 5000 }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := base
+			if tt.opts != nil {
+				tt.opts(&opts)
+			}
+			g := &Generator{MaxPixels: tt.maxPixels}
+			if _, err := g.GenerateImage(opts); (err != nil) != tt.wantErr {
+				t.Errorf("GenerateImage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	g := &Generator{MaxPixels: 100 * 100}
+	if _, err := g.RenderMatrix([][]bool{{true}}, RenderOptions{Options: Options{Size: 101}}); err == nil {
+		t.Error("RenderMatrix() over the pixel limit should fail")
+	}
+	if err := (&Generator{MaxPixels: -1}).checkPixels(100000, Options{}); err != nil {
+		t.Errorf("checkPixels() with the limit disabled error = %v", err)
+	}
+}
+
+func TestGenerator_Shortener(t *testing.T) {
+	long := "https://example.com/" + strings.Repeat("path/", 60)
+	var calls []string
+	g := &Generator{Shortener: func(data string) (string, error) {
+		calls = append(calls, data)
+		return "https://s.example/abc", nil
+	}}
+
+	_, info, err := g.GenerateWithInfo(Options{Data: long})
+	if err != nil {
+		t.Fatalf("GenerateWithInfo() error = %v", err)
+	}
+	_, want, err := GenerateWithInfo(Options{Data: "https://s.example/abc"})
+	if err != nil {
+		t.Fatalf("GenerateWithInfo() error = %v", err)
+	}
+	if info.Version != want.Version {
+		t.Errorf("Version = %d, want %d for the shortened data", info.Version, want.Version)
+	}
+	if len(calls) != 1 || calls[0] != long {
+		t.Errorf("Shortener called with %q, want the original data once", calls)
+	}
+
+	calls = nil
+	if _, err := g.GenerateSVG(Options{Data: long}); err != nil {
+		t.Fatalf("GenerateSVG() error = %v", err)
+	}
+	if len(calls) != 1 {
+		t.Errorf("GenerateSVG() called Shortener %d times, want 1", len(calls))
+	}
+
+	calls = nil
+	if _, err := g.GenerateUnderSize(Options{Data: long, Size: 512}, 2000, "png"); err != nil {
+		t.Fatalf("GenerateUnderSize() error = %v", err)
+	}
+	if len(calls) != 1 {
+		t.Errorf("GenerateUnderSize() called Shortener %d times, want 1", len(calls))
+	}
+
+	calls = nil
+	if _, err := g.GenerateResponsive(Options{Data: long, Size: 100}, []int{1, 2, 3}); err != nil {
+		t.Fatalf("GenerateResponsive() error = %v", err)
+	}
+	if len(calls) != 1 {
+		t.Errorf("GenerateResponsive() called Shortener %d times, want 1", len(calls))
+	}
+
+	calls = nil
+	results := g.GenerateBatchUniform([]Options{{Data: long, Size: 200}, {Data: long + "x", Size: 200}}, BatchOptions{})
+	for i, r := range results {
+		if r.Err != nil {
+			t.Fatalf("GenerateBatchUniform() item %d error = %v", i, r.Err)
+		}
+	}
+	if len(calls) != 2 {
+		t.Errorf("GenerateBatchUniform() called Shortener %d times, want once per item", len(calls))
+	}
+
+	failing := &Generator{Shortener: func(string) (string, error) {
+		return "", errors.New("shortener unavailable")
+	}}
+	if _, err := failing.GeneratePNG(Options{Data: long}); err == nil || !strings.Contains(err.Error(), "shortener unavailable") {
+		t.Errorf("GeneratePNG() with a failing shortener error = %v, want the shortener error", err)
+	}
+}
+
+func TestGenerator_MultipleCalls(t *testing.T) {
+	generator := New()
+
+	opts1 := Options{
+		Data: "https://example.com/first",
+		Size: 300,
+	}
+
+	opts2 := Options{
+		Data: "https://example.com/second",
+		Size: 300,
+	}
+
+	pngData1, err1 := generator.GeneratePNG(opts1)
+	pngData2, err2 := generator.GeneratePNG(opts2)
+
+	if err1 != nil {
+		t.Errorf("First GeneratePNG() error = %v", err1)
+	}
+	if err2 != nil {
+		t.Errorf("Second GeneratePNG() error = %v", err2)
+	}
+
+	if len(pngData1) == 0 {
+		t.Error("First GeneratePNG() returned empty PNG")
+	}
+	if len(pngData2) == 0 {
+		t.Error("Second GeneratePNG() returned empty PNG")
+	}
+
+	// Verify both are valid PNGs
+	_, err := png.Decode(bytes.NewReader(pngData1))
+	if err != nil {
+		t.Errorf("First PNG is invalid: %v", err)
+	}
+
+	_, err = png.Decode(bytes.NewReader(pngData2))
+	if err != nil {
+		t.Errorf("Second PNG is invalid: %v", err)
+	}
+
+	// PNGs with different data should produce different results
+	// (though exact byte comparison might fail due to compression)
+	if len(pngData1) == len(pngData2) && bytes.Equal(pngData1, pngData2) {
+		// If they're identical, at least verify they're valid
+		// This can happen with very similar QR codes, but is unlikely
+		t.Logf("Warning: PNGs with different data produced identical output (size: %d bytes)", len(pngData1))
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		valid bool
+	}{
+		{"black", "black", true},
+		{"white", "white", true},
+		{"red", "red", true},
+		{"green", "green", true},
+		{"blue", "blue", true},
+		{"RGB format", "rgb(255,0,0)", true},
+		{"RGBA format", "rgba(255,0,0,128)", true},
+		{"invalid format", "invalid", true}, // Should return default (black)
+		{"empty string", "", true},          // Should return default
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			color := parseColor(tt.input)
+			if color == nil {
+				t.Error("parseColor() returned nil")
+			}
+		})
+	}
+}
+
+func TestParseColor_Hex(t *testing.T) {
+	black := color.Black
+	tests := []struct {
+		name  string
+		input string
+		want  color.Color
+	}{
+		{"short form", "#f00", color.NRGBA{R: 255, A: 255}},
+		{"short form expands nibbles", "#1a3", color.NRGBA{R: 0x11, G: 0xaa, B: 0x33, A: 255}},
+		{"long form", "#00ff80", color.NRGBA{G: 255, B: 0x80, A: 255}},
+		{"long form with alpha", "#0000ff80", color.NRGBA{B: 255, A: 0x80}},
+		{"upper case", "#FFA500", color.NRGBA{R: 255, G: 0xa5, A: 255}},
+		{"mixed case short form", "#AbC", color.NRGBA{R: 0xaa, G: 0xbb, B: 0xcc, A: 255}},
+		{"without hash", "ff0000", color.NRGBA{R: 255, A: 255}},
+		{"short form without hash", "0f0", color.NRGBA{G: 255, A: 255}},
+		{"only a hash", "#", black},
+		{"wrong length", "#ff00", black},
+		{"five digits", "#ff000", black},
+		{"seven digits", "#ff00000", black},
+		{"too long", "#ff0000ff00", black},
+		{"non-hex digits", "#gg0000", black},
+		{"non-hex short form", "#xyz", black},
+		{"sign", "#+f0000", black},
+		{"double hash", "##f00", black},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := color.NRGBAModel.Convert(parseColor(tt.input))
+			if want := color.NRGBAModel.Convert(tt.want); got != want {
+				t.Errorf("parseColor(%q) = %v, want %v", tt.input, got, want)
+			}
+		})
+	}
+}
+
+func TestModuleColors_Fallback(t *testing.T) {
+	black := color.RGBAModel.Convert(color.Black)
+	white := color.RGBAModel.Convert(color.White)
+	red := color.RGBA{R: 255, A: 255}
+	tests := []struct {
+		name       string
+		foreground string
+		background string
+		invert     bool
+		wantFG     color.Color
+		wantBG     color.Color
+	}{
+		{name: "unset", wantFG: black, wantBG: white},
+		{name: "invalid background", foreground: "white", background: "#00000", wantFG: white, wantBG: white},
+		{name: "invalid foreground", foreground: "navy", background: "red", wantFG: black, wantBG: red},
+		{name: "valid colors", foreground: "red", background: "black", wantFG: red, wantBG: black},
+		{name: "invert after fallback", foreground: "navy", background: "#ffff", invert: true, wantFG: white, wantBG: black},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fg, bg := moduleColors(Options{Foreground: tt.foreground, Background: tt.background, Invert: tt.invert})
+			if got := color.RGBAModel.Convert(fg); got != tt.wantFG {
+				t.Errorf("foreground = %v, want %v", got, tt.wantFG)
+			}
+			if got := color.RGBAModel.Convert(bg); got != tt.wantBG {
+				t.Errorf("background = %v, want %v", got, tt.wantBG)
+			}
+		})
+	}
+}
+
+func TestModuleCountFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		dataLen  int
+		level    string
+		min, max int
+	}{
+		{"single byte", 1, "L", 21, 21},
+		// Version 1 at M holds 34 digits but only 14 bytes
+		{"numeric fits version 1", 20, "M", 21, 25},
+		{"default level", 20, "", 21, 25},
+		{"higher level", 20, "H", 25, 29},
+		// 4000 digits fit version 30 at L, 4000 bytes do not fit even version 40
+		{"only numeric fits", 4000, "L", 137, 177},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			min, max, err := ModuleCountFor(tt.dataLen, tt.level)
+			if err != nil {
+				t.Fatalf("ModuleCountFor() error = %v", err)
+			}
+			if min != tt.min || max != tt.max {
+				t.Errorf("ModuleCountFor(%d, %q) = %d, %d, want %d, %d", tt.dataLen, tt.level, min, max, tt.min, tt.max)
+			}
+		})
+	}
+
+	for _, tooLong := range []struct {
+		dataLen int
+		level   string
+	}{
+		{8000, "L"},
+		{7090, "L"},
+		{3058, "H"},
+		{math.MaxInt, "M"},
+	} {
+		if _, _, err := ModuleCountFor(tooLong.dataLen, tooLong.level); !errors.Is(err, ErrDataTooLong) {
+			t.Errorf("ModuleCountFor(%d, %q) error = %v, want ErrDataTooLong", tooLong.dataLen, tooLong.level, err)
+		}
+	}
+	for _, invalid := range []struct {
+		dataLen int
+		level   string
+	}{
+		{0, "M"},
+		{-1, "M"},
+		{10, "X"},
+		{10, "LM"},
+	} {
+		if _, _, err := ModuleCountFor(invalid.dataLen, invalid.level); err == nil {
+			t.Errorf("ModuleCountFor(%d, %q) should fail", invalid.dataLen, invalid.level)
+		}
+	}
 }
 
 func TestGetErrorCorrection(t *testing.T) {
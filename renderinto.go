@@ -0,0 +1,86 @@
+package qrcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"time"
+)
+
+// RenderInto draws the code for opts into dst, which must have exactly the dimensions reported by
+// OutputBounds. Only with Fast and no ColorModel are the modules written straight into dst, so
+// reusing dst saves the allocation; all other options (and Fast codes that ForceExactSize
+// scales down) still render into a newly allocated image that is then copied into dst
+func (g *Generator) RenderInto(dst *image.RGBA, opts Options) error {
+	if dst == nil {
+		return fmt.Errorf("destination image is required")
+	}
+	start := time.Now()
+	if opts.Fast && opts.ColorModel == "" {
+		opts = g.withDefaults(opts)
+		if err := g.shorten(&opts); err != nil {
+			return err
+		}
+		// opts stays unprepared for the fallback to render below
+		prepared := opts
+		qr, err := g.prepare(&prepared)
+		if err != nil {
+			return err
+		}
+		if size := codeSize(qr, prepared); size >= totalModules(qr) {
+			if err := checkRenderSize(dst, image.Pt(size, size)); err != nil {
+				return err
+			}
+			if g.OnEvent != nil {
+				g.OnEvent(EventEncoded, map[string]any{"elapsed": time.Since(start), "version": qr.VersionNumber})
+			}
+			drawModulesInto(dst, qr.Bitmap(), qr.ForegroundColor, qr.BackgroundColor)
+			return nil
+		}
+	}
+
+	img, _, err := g.render(opts, start)
+	if err != nil {
+		return err
+	}
+	if err := checkRenderSize(dst, img.Bounds().Size()); err != nil {
+		return err
+	}
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Src)
+	return nil
+}
+
+// RenderInto is a convenience function that creates a generator and draws a QR code into dst
+func RenderInto(dst *image.RGBA, opts Options) error {
+	g := New()
+	return g.RenderInto(dst, opts)
+}
+
+// checkRenderSize reports an error when dst does not have the dimensions of the rendered code
+func checkRenderSize(dst *image.RGBA, want image.Point) error {
+	if got := dst.Bounds().Size(); got != want {
+		return fmt.Errorf("destination image is %dx%d pixels, want %dx%d", got.X, got.Y, want.X, want.Y)
+	}
+	return nil
+}
+
+// drawModulesInto fills dst with bitmap scaled to its width, mapping pixels to modules like
+// drawModules
+func drawModulesInto(dst *image.RGBA, bitmap [][]bool, fg, bg color.Color) {
+	dark := color.RGBAModel.Convert(fg).(color.RGBA)
+	light := color.RGBAModel.Convert(bg).(color.RGBA)
+	bounds := dst.Bounds()
+	size := bounds.Dx()
+	modulesPerPixel := float64(len(bitmap)) / float64(size)
+	for y := 0; y < size; y++ {
+		row := bitmap[int(float64(y)*modulesPerPixel)]
+		for x := 0; x < size; x++ {
+			c := light
+			if row[int(float64(x)*modulesPerPixel)] {
+				c = dark
+			}
+			dst.SetRGBA(bounds.Min.X+x, bounds.Min.Y+y, c)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package qrcode
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestRenderInto(t *testing.T) {
+	base := Options{
+		Data:       "https://example.com",
+		Size:       250,
+		Foreground: "rgb(10,20,30)",
+		Background: "white",
+		Border:     4,
+	}
+	tests := []struct {
+		name string
+		opts func(*Options)
+	}{
+		{name: "fast", opts: func(o *Options) { o.Fast = true }},
+		{name: "fast with color model", opts: func(o *Options) { o.Fast = true; o.ColorModel = "gray" }},
+		{name: "fast scaled down", opts: func(o *Options) { o.Fast = true; o.ForceExactSize = true; o.Size = 20 }},
+		{name: "styled", opts: func(o *Options) { o.EyeColor = "red"; o.Caption = "Scan me" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := base
+			tt.opts(&opts)
+			want, err := GenerateImage(opts)
+			if err != nil {
+				t.Fatalf("GenerateImage() error = %v", err)
+			}
+			// Render into a sub-image to check that a non-zero origin is honored
+			size := want.Bounds().Size()
+			buffer := image.NewRGBA(image.Rect(0, 0, size.X+10, size.Y+10))
+			dst := buffer.SubImage(image.Rect(10, 10, size.X+10, size.Y+10)).(*image.RGBA)
+			for i := 0; i < 2; i++ {
+				if err := RenderInto(dst, opts); err != nil {
+					t.Fatalf("RenderInto() error = %v", err)
+				}
+			}
+			for y := 0; y < size.Y; y++ {
+				for x := 0; x < size.X; x++ {
+					w := color.RGBAModel.Convert(want.At(x, y))
+					if got := dst.At(x+10, y+10); got != w {
+						t.Fatalf("pixel (%d,%d) = %v, want %v", x, y, got, w)
+					}
+				}
+			}
+		})
+	}
+
+	for _, tt := range []struct {
+		name string
+		dst  *image.RGBA
+	}{
+		{"nil destination", nil},
+		{"too small", image.NewRGBA(image.Rect(0, 0, 100, 100))},
+		{"not square", image.NewRGBA(image.Rect(0, 0, 250, 300))},
+	} {
+		for _, fast := range []bool{false, true} {
+			opts := base
+			opts.Fast = fast
+			if err := RenderInto(tt.dst, opts); err == nil {
+				t.Errorf("%s: RenderInto() with Fast %v should fail", tt.name, fast)
+			}
+		}
+	}
+}
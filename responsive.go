@@ -0,0 +1,51 @@
+package qrcode
+
+import "fmt"
+
+// GenerateResponsive generates PNG variants of a QR code for each of scales (e.g. 1, 2, 3 for
+// srcset), keyed by scale. The code is first snapped to whole-pixel modules at scale 1 (see
+// SnapToModule), and each variant is exactly scale times that size, so modules stay crisp at
+// every scale. Other pixel options, such as CaptionHeight and LogoOffsetX, are not scaled
+func (g *Generator) GenerateResponsive(opts Options, scales []int) (map[int][]byte, error) {
+	opts = g.withDefaults(opts)
+	if opts.ForceExactSize {
+		return nil, fmt.Errorf("responsive variants cannot be combined with force exact size")
+	}
+	if err := resolvePhysicalSize(&opts); err != nil {
+		return nil, err
+	}
+	if err := g.shorten(&opts); err != nil {
+		return nil, err
+	}
+	opts.SnapToModule = true
+	probe := opts
+	if _, err := g.prepare(&probe); err != nil {
+		return nil, err
+	}
+
+	variants := make(map[int][]byte, len(scales))
+	for _, scale := range scales {
+		if scale <= 0 {
+			return nil, fmt.Errorf("invalid scale %d: must be positive", scale)
+		}
+		if _, ok := variants[scale]; ok {
+			continue
+		}
+		// probe.Size is the snapped code size at scale 1; prepare adds the border padding back
+		scaled := opts
+		scaled.Size = probe.Size*scale - borderPadding(opts)
+		data, err := g.GeneratePNG(scaled)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate %dx variant: %w", scale, err)
+		}
+		variants[scale] = data
+	}
+	return variants, nil
+}
+
+// GenerateResponsive is a convenience function that creates a generator and generates a QR
+// code at several scales
+func GenerateResponsive(opts Options, scales []int) (map[int][]byte, error) {
+	g := New()
+	return g.GenerateResponsive(opts, scales)
+}
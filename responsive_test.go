@@ -0,0 +1,75 @@
+package qrcode
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func TestGenerateResponsive(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		border    int
+		wantSizes map[int]int
+	}{
+		// Version 2: 25 modules, 33 with the quiet zone
+		{name: "snapped", size: 310, wantSizes: map[int]int{1: 325, 2: 650, 3: 975}},
+		{name: "wide border", size: 300, border: 6, wantSizes: map[int]int{1: 330, 2: 660}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var scales []int
+			for scale := range tt.wantSizes {
+				scales = append(scales, scale)
+			}
+			variants, err := GenerateResponsive(Options{
+				Data:       "https://example.com",
+				Size:       tt.size,
+				Border:     tt.border,
+				Foreground: "black",
+				Background: "white",
+			}, scales)
+			if err != nil {
+				t.Fatalf("GenerateResponsive() error = %v", err)
+			}
+			images := make(map[int]image.Image)
+			for scale, want := range tt.wantSizes {
+				img, err := png.Decode(bytes.NewReader(variants[scale]))
+				if err != nil {
+					t.Fatalf("GenerateResponsive() returned invalid PNG for %dx: %v", scale, err)
+				}
+				if got := img.Bounds().Dx(); got != want {
+					t.Errorf("%dx width = %d, want %d", scale, got, want)
+				}
+				images[scale] = img
+			}
+
+			// Crisp modules: the 2x variant is the 1x variant with every pixel doubled
+			one, two := images[1], images[2]
+			for y := 0; y < two.Bounds().Dy(); y++ {
+				for x := 0; x < two.Bounds().Dx(); x++ {
+					r1, _, _, _ := one.At(x/2, y/2).RGBA()
+					r2, _, _, _ := two.At(x, y).RGBA()
+					if r1 != r2 {
+						t.Fatalf("2x pixel (%d,%d) does not match 1x pixel (%d,%d)", x, y, x/2, y/2)
+					}
+				}
+			}
+		})
+	}
+
+	for _, invalid := range []struct {
+		opts   Options
+		scales []int
+	}{
+		{Options{Data: "test"}, []int{1, 0}},
+		{Options{Data: "test", ForceExactSize: true}, []int{1}},
+		{Options{}, []int{1}},
+	} {
+		if _, err := GenerateResponsive(invalid.opts, invalid.scales); err == nil {
+			t.Errorf("GenerateResponsive(%+v, %v) should fail", invalid.opts, invalid.scales)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package qrcode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SavePNG generates a QR code as a PNG and writes it to path. When path is an existing
+// directory, the file is named after the SHA-256 of its content ("<hash>.png") for use with
+// immutable caching. It returns the path written
+func (g *Generator) SavePNG(opts Options, path string) (string, error) {
+	data, err := g.GeneratePNG(opts)
+	if err != nil {
+		return "", err
+	}
+	return saveFile(data, path, ".png")
+}
+
+// SaveSVG generates a QR code as an SVG and writes it to path, naming the file "<hash>.svg"
+// when path is an existing directory like SavePNG. It returns the path written
+func (g *Generator) SaveSVG(opts Options, path string) (string, error) {
+	data, err := g.GenerateSVG(opts)
+	if err != nil {
+		return "", err
+	}
+	return saveFile(data, path, ".svg")
+}
+
+// SavePNG is a convenience function that creates a generator and saves a PNG QR code
+func SavePNG(opts Options, path string) (string, error) {
+	g := New()
+	return g.SavePNG(opts, path)
+}
+
+// SaveSVG is a convenience function that creates a generator and saves an SVG QR code
+func SaveSVG(opts Options, path string) (string, error) {
+	g := New()
+	return g.SaveSVG(opts, path)
+}
+
+// saveFile writes data to path, or to a content-addressed file with extension ext when path is
+// a directory. Existing files are overwritten
+func saveFile(data []byte, path, ext string) (string, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		sum := sha256.Sum256(data)
+		path = filepath.Join(path, hex.EncodeToString(sum[:])+ext)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to save qrcode: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,64 @@
+package qrcode
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSavePNG(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{Data: "https://example.com", Size: 200}
+	want, err := GeneratePNG(opts)
+	if err != nil {
+		t.Fatalf("GeneratePNG() error = %v", err)
+	}
+	sum := sha256.Sum256(want)
+
+	tests := []struct {
+		name     string
+		path     string
+		wantPath string
+		wantErr  bool
+	}{
+		{name: "file path", path: filepath.Join(dir, "code.png"), wantPath: filepath.Join(dir, "code.png")},
+		{name: "directory", path: dir, wantPath: filepath.Join(dir, hex.EncodeToString(sum[:])+".png")},
+		{name: "directory again overwrites", path: dir, wantPath: filepath.Join(dir, hex.EncodeToString(sum[:])+".png")},
+		{name: "missing parent", path: filepath.Join(dir, "missing", "code.png"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := SavePNG(opts, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SavePNG() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if path != tt.wantPath {
+				t.Errorf("SavePNG() path = %s, want %s", path, tt.wantPath)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("reading saved file failed: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Error("saved file differs from GeneratePNG() output")
+			}
+		})
+	}
+}
+
+func TestSaveSVG(t *testing.T) {
+	dir := t.TempDir()
+	path, err := SaveSVG(Options{Data: "https://example.com"}, dir)
+	if err != nil {
+		t.Fatalf("SaveSVG() error = %v", err)
+	}
+	if filepath.Ext(path) != ".svg" || filepath.Dir(path) != dir {
+		t.Errorf("SaveSVG() path = %s, want a .svg file in %s", path, dir)
+	}
+}
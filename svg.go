@@ -0,0 +1,115 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+)
+
+// SVG class names used when Options.SVGUseClasses is set
+const (
+	SVGClassDark  = "qr-dark"
+	SVGClassLight = "qr-light"
+	SVGClassEye   = "qr-eye"
+)
+
+// GenerateSVG generates a QR code as an SVG document with one rect per dark module
+// Size, Foreground, Background, Border and Error are honored; raster effects such as
+// gradients and logos are not applied
+func (g *Generator) GenerateSVG(opts Options) ([]byte, error) {
+	opts = g.withDefaults(opts)
+	qr, err := g.prepare(&opts)
+	if err != nil {
+		return nil, err
+	}
+
+	bitmap := qr.Bitmap()
+	kinds := classifyModules(qr.VersionNumber)
+	quietZone := 0
+	if !qr.DisableBorder {
+		quietZone = quietZoneModules
+	}
+	n := len(bitmap)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" shape-rendering="crispEdges">`,
+		n, n, opts.Size, opts.Size)
+	if opts.SVGUseClasses {
+		fmt.Fprintf(&buf, `<rect class="%s" width="%d" height="%d"/>`, SVGClassLight, n, n)
+	} else {
+		fmt.Fprintf(&buf, `<rect width="%d" height="%d" %s/>`, n, n, svgFill(qr.BackgroundColor))
+	}
+
+	foreground := svgFill(qr.ForegroundColor)
+	if opts.SVGMergePath {
+		var dark, eye bytes.Buffer
+		for y, row := range bitmap {
+			for x := 0; x < len(row); {
+				if !row[x] {
+					x++
+					continue
+				}
+				// Merge the run of dark modules of the same class starting at x
+				isEye := opts.SVGUseClasses && isFinderModule(kinds, x-quietZone, y-quietZone)
+				end := x + 1
+				for end < len(row) && row[end] && (opts.SVGUseClasses && isFinderModule(kinds, end-quietZone, y-quietZone)) == isEye {
+					end++
+				}
+				path := &dark
+				if isEye {
+					path = &eye
+				}
+				fmt.Fprintf(path, "M%d %dh%dv1h-%dz", x, y, end-x, end-x)
+				x = end
+			}
+		}
+		if opts.SVGUseClasses {
+			fmt.Fprintf(&buf, `<path class="%s" d="%s"/>`, SVGClassDark, dark.String())
+			fmt.Fprintf(&buf, `<path class="%s" d="%s"/>`, SVGClassEye, eye.String())
+		} else {
+			fmt.Fprintf(&buf, `<path d="%s" %s/>`, dark.String(), foreground)
+		}
+		buf.WriteString(`</svg>`)
+		return buf.Bytes(), nil
+	}
+
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			if !opts.SVGUseClasses {
+				fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="1" height="1" %s/>`, x, y, foreground)
+				continue
+			}
+			class := SVGClassDark
+			if isFinderModule(kinds, x-quietZone, y-quietZone) {
+				class = SVGClassEye
+			}
+			fmt.Fprintf(&buf, `<rect class="%s" x="%d" y="%d" width="1" height="1"/>`, class, x, y)
+		}
+	}
+	buf.WriteString(`</svg>`)
+	return buf.Bytes(), nil
+}
+
+// GenerateSVG is a convenience function that creates a generator and generates an SVG QR code
+func GenerateSVG(opts Options) ([]byte, error) {
+	g := New()
+	return g.GenerateSVG(opts)
+}
+
+// isFinderModule reports whether the symbol module (mx, my) belongs to a finder pattern
+func isFinderModule(kinds [][]moduleKind, mx, my int) bool {
+	return mx >= 0 && my >= 0 && mx < len(kinds) && my < len(kinds) && kinds[my][mx] == moduleFinder
+}
+
+// svgFill returns the fill attribute(s) for c, adding fill-opacity for translucent colors
+func svgFill(c color.Color) string {
+	rgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	fill := fmt.Sprintf(`fill="#%02x%02x%02x"`, rgba.R, rgba.G, rgba.B)
+	if rgba.A != 255 {
+		fill += fmt.Sprintf(` fill-opacity="%.3f"`, float64(rgba.A)/255)
+	}
+	return fill
+}
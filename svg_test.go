@@ -0,0 +1,181 @@
+package qrcode
+
+import (
+	"encoding/xml"
+	"fmt"
+	"image"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type svgDoc struct {
+	XMLName xml.Name  `xml:"svg"`
+	Width   int       `xml:"width,attr"`
+	Rects   []svgRect `xml:"rect"`
+}
+
+type svgRect struct {
+	Class string `xml:"class,attr"`
+	Fill  string `xml:"fill,attr"`
+}
+
+func TestGenerateSVG(t *testing.T) {
+	tests := []struct {
+		name       string
+		useClasses bool
+	}{
+		{"inline fills", false},
+		{"css classes", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svgData, err := GenerateSVG(Options{
+				Data:          "https://example.com",
+				Size:          250,
+				Foreground:    "rgb(0,0,255)",
+				Background:    "white",
+				Border:        4,
+				SVGUseClasses: tt.useClasses,
+			})
+			if err != nil {
+				t.Fatalf("GenerateSVG() error = %v", err)
+			}
+
+			var doc svgDoc
+			if err := xml.Unmarshal(svgData, &doc); err != nil {
+				t.Fatalf("GenerateSVG() returned invalid SVG: %v", err)
+			}
+			if doc.Width != 250 {
+				t.Errorf("GenerateSVG() width = %d, want 250", doc.Width)
+			}
+			if len(doc.Rects) < 2 {
+				t.Fatalf("GenerateSVG() returned %d rects", len(doc.Rects))
+			}
+
+			classes := map[string]int{}
+			for _, r := range doc.Rects {
+				if tt.useClasses && r.Fill != "" {
+					t.Fatalf("GenerateSVG() with classes emitted inline fill %q", r.Fill)
+				}
+				if !tt.useClasses && r.Class != "" {
+					t.Fatalf("GenerateSVG() without classes emitted class %q", r.Class)
+				}
+				classes[r.Class]++
+			}
+
+			if tt.useClasses {
+				// Background plus three 7x7 finders with 33 dark modules each (ring and center)
+				if classes[SVGClassLight] != 1 {
+					t.Errorf("got %d %s rects, want 1", classes[SVGClassLight], SVGClassLight)
+				}
+				if classes[SVGClassEye] != 3*33 {
+					t.Errorf("got %d %s rects, want %d", classes[SVGClassEye], SVGClassEye, 3*33)
+				}
+				if classes[SVGClassDark] == 0 {
+					t.Errorf("got no %s rects", SVGClassDark)
+				}
+			} else if doc.Rects[0].Fill != "#ffffff" || doc.Rects[1].Fill != "#0000ff" {
+				t.Errorf("GenerateSVG() fills = %q, %q", doc.Rects[0].Fill, doc.Rects[1].Fill)
+			}
+		})
+	}
+}
+
+// svgPathRun matches one merged run of modules in a path drawn by SVGMergePath
+var svgPathRun = regexp.MustCompile(`M(\d+) (\d+)h(\d+)v1h-(\d+)z`)
+
+func TestGenerateSVG_MergePath(t *testing.T) {
+	for _, useClasses := range []bool{false, true} {
+		t.Run(fmt.Sprintf("classes=%v", useClasses), func(t *testing.T) {
+			opts := Options{
+				Data:          "https://example.com/a/rather/long/path?with=query",
+				Border:        4,
+				SVGUseClasses: useClasses,
+			}
+			rectSVG, err := GenerateSVG(opts)
+			if err != nil {
+				t.Fatalf("GenerateSVG() error = %v", err)
+			}
+			opts.SVGMergePath = true
+			pathSVG, err := GenerateSVG(opts)
+			if err != nil {
+				t.Fatalf("GenerateSVG() with SVGMergePath error = %v", err)
+			}
+			if len(pathSVG)*3 > len(rectSVG) {
+				t.Errorf("merged SVG is %d bytes, want well below the %d bytes of rects", len(pathSVG), len(rectSVG))
+			}
+
+			var rects struct {
+				Rects []struct {
+					Class string `xml:"class,attr"`
+					X     string `xml:"x,attr"`
+					Y     string `xml:"y,attr"`
+				} `xml:"rect"`
+			}
+			if err := xml.Unmarshal(rectSVG, &rects); err != nil {
+				t.Fatalf("invalid rect SVG: %v", err)
+			}
+			want := map[image.Point]string{}
+			for _, r := range rects.Rects {
+				if r.X == "" {
+					continue // background
+				}
+				x, _ := strconv.Atoi(r.X)
+				y, _ := strconv.Atoi(r.Y)
+				want[image.Pt(x, y)] = r.Class
+			}
+
+			var paths struct {
+				Rects []struct{} `xml:"rect"`
+				Paths []struct {
+					Class string `xml:"class,attr"`
+					D     string `xml:"d,attr"`
+				} `xml:"path"`
+			}
+			if err := xml.Unmarshal(pathSVG, &paths); err != nil {
+				t.Fatalf("invalid path SVG: %v", err)
+			}
+			if len(paths.Rects) != 1 {
+				t.Errorf("merged SVG has %d rects, want only the background", len(paths.Rects))
+			}
+			got := map[image.Point]string{}
+			for _, p := range paths.Paths {
+				for _, run := range svgPathRun.FindAllStringSubmatch(p.D, -1) {
+					x, _ := strconv.Atoi(run[1])
+					y, _ := strconv.Atoi(run[2])
+					n, _ := strconv.Atoi(run[3])
+					for i := 0; i < n; i++ {
+						got[image.Pt(x+i, y)] = p.Class
+					}
+				}
+			}
+
+			if len(got) != len(want) {
+				t.Fatalf("path covers %d modules, rects cover %d", len(got), len(want))
+			}
+			for pt, class := range want {
+				if gotClass, ok := got[pt]; !ok || gotClass != class {
+					t.Fatalf("module %v: path class %q (present %v), rect class %q", pt, gotClass, ok, class)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateSVG_EmptyData(t *testing.T) {
+	if _, err := GenerateSVG(Options{}); err == nil {
+		t.Error("GenerateSVG() with empty data should fail")
+	}
+}
+
+func TestSVGFill(t *testing.T) {
+	if got := svgFill(parseColor("rgba(255,0,0,255)")); got != `fill="#ff0000"` {
+		t.Errorf("svgFill() = %s", got)
+	}
+	if got := svgFill(parseColor("rgba(0,0,0,0)")); !strings.Contains(got, `fill-opacity="0.000"`) {
+		t.Errorf("svgFill() = %s, want fill-opacity", got)
+	}
+}
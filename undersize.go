@@ -0,0 +1,106 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// GenerateUnderSize generates the best image in format ("png" or "jpeg") whose encoded size
+// does not exceed maxBytes. JPEG output is rendered at opts.Size and searches for the highest
+// encoder quality that fits; lossless PNG output searches for the largest size up to opts.Size
+// instead. It fails if even the lowest quality or smallest size exceeds maxBytes
+func (g *Generator) GenerateUnderSize(opts Options, maxBytes int, format string) ([]byte, error) {
+	opts = g.withDefaults(opts)
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("max bytes must be positive")
+	}
+	if err := g.shorten(&opts); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	var err error
+	switch format {
+	case "jpeg", "jpg":
+		data, err = g.jpegUnderSize(opts, maxBytes)
+	case "png":
+		data, err = g.pngUnderSize(opts, maxBytes)
+	default:
+		return nil, fmt.Errorf("unsupported format %q: must be png or jpeg", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	g.generated.Add(1)
+	g.bytesOut.Add(uint64(len(data)))
+	return data, nil
+}
+
+// GenerateUnderSize is a convenience function that creates a generator and generates a QR code
+// no larger than maxBytes
+func GenerateUnderSize(opts Options, maxBytes int, format string) ([]byte, error) {
+	g := New()
+	return g.GenerateUnderSize(opts, maxBytes, format)
+}
+
+// jpegUnderSize binary-searches the JPEG quality for the best encoding of at most maxBytes
+func (g *Generator) jpegUnderSize(opts Options, maxBytes int) ([]byte, error) {
+	img, _, err := g.render(opts, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var best []byte
+	low, high := 1, 100
+	for low <= high {
+		quality := (low + high) / 2
+		data, err := encodeJPEG(img, quality)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) <= maxBytes {
+			best = data
+			low = quality + 1
+		} else {
+			high = quality - 1
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("jpeg at minimum quality exceeds %d bytes", maxBytes)
+	}
+	return best, nil
+}
+
+// pngUnderSize binary-searches the image size for the largest PNG of at most maxBytes
+func (g *Generator) pngUnderSize(opts Options, maxBytes int) ([]byte, error) {
+	if err := resolvePhysicalSize(&opts); err != nil {
+		return nil, err
+	}
+	applyDefaults(&opts)
+
+	var best []byte
+	low, high := 1, opts.Size
+	for low <= high {
+		sizeOpts := opts
+		sizeOpts.Size = (low + high) / 2
+		img, _, err := g.render(sizeOpts, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := writePNG(&buf, img, sizeOpts); err != nil {
+			return nil, err
+		}
+		if data := buf.Bytes(); len(data) <= maxBytes {
+			best = data
+			low = sizeOpts.Size + 1
+		} else {
+			high = sizeOpts.Size - 1
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("png at minimum size exceeds %d bytes", maxBytes)
+	}
+	return best, nil
+}
@@ -0,0 +1,63 @@
+package qrcode
+
+import (
+	"bytes"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"testing"
+)
+
+func TestGenerateUnderSize(t *testing.T) {
+	opts := Options{
+		Data:          "https://example.com",
+		Size:          600,
+		Foreground:    "black",
+		Background:    "white",
+		GradientStart: "rgb(255,0,0)",
+		GradientEnd:   "rgb(0,0,255)",
+	}
+	full, err := GeneratePNG(opts)
+	if err != nil {
+		t.Fatalf("GeneratePNG() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		maxBytes int
+		wantErr  bool
+	}{
+		{name: "jpeg", format: "jpeg", maxBytes: 8000},
+		{name: "png", format: "png", maxBytes: len(full) / 2},
+		{name: "png fits at full size", format: "png", maxBytes: len(full)},
+		{name: "jpeg too small", format: "jpeg", maxBytes: 100, wantErr: true},
+		{name: "png too small", format: "png", maxBytes: 50, wantErr: true},
+		{name: "unsupported format", format: "webp", maxBytes: 8000, wantErr: true},
+		{name: "non-positive limit", format: "png", maxBytes: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := GenerateUnderSize(opts, tt.maxBytes, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateUnderSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(data) > tt.maxBytes {
+				t.Errorf("GenerateUnderSize() returned %d bytes, want at most %d", len(data), tt.maxBytes)
+			}
+			img, format, err := image.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("GenerateUnderSize() returned invalid image: %v", err)
+			}
+			if format != tt.format {
+				t.Errorf("GenerateUnderSize() format = %s, want %s", format, tt.format)
+			}
+			if tt.maxBytes == len(full) && img.Bounds().Dx() != opts.Size {
+				t.Errorf("GenerateUnderSize() width = %d, want %d", img.Bounds().Dx(), opts.Size)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package qrcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// Watermark opacity bounds: the default, and the maximum that keeps light modules readable
+const (
+	defaultWatermarkOpacity = 0.08
+	maxWatermarkOpacity     = 0.2
+)
+
+// drawWatermark tiles opts.BackgroundWatermark over img through light, offsetting every other
+// row by half a tile. Text lines are a twelfth of the image high
+func drawWatermark(img *image.RGBA, light *image.Alpha, opts Options, fg color.Color) error {
+	opacity := opts.WatermarkOpacity
+	if opacity == 0 {
+		opacity = defaultWatermarkOpacity
+	}
+	if opacity < 0 || opacity > maxWatermarkOpacity {
+		return fmt.Errorf("watermark opacity must be between 0 and %.1f", maxWatermarkOpacity)
+	}
+	c := fg
+	if opts.WatermarkColor != "" {
+		c = parseColor(opts.WatermarkColor)
+	}
+	nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+	nc.A = uint8(float64(nc.A) * opacity)
+
+	bounds := img.Bounds()
+	lineHeight := max(12, bounds.Dy()/12)
+	face, err := captionFace(opts.CaptionFont, lineHeight)
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+
+	layer := image.NewRGBA(bounds)
+	drawer := &font.Drawer{Dst: layer, Src: image.NewUniform(nc), Face: face}
+	gap := fixed.I(lineHeight)
+	step := drawer.MeasureString(opts.BackgroundWatermark) + gap
+	for row, y := 0, face.Metrics().Ascent; y < fixed.I(bounds.Max.Y)+gap; row, y = row+1, y+fixed.I(lineHeight) {
+		x := fixed.I(bounds.Min.X) - step/2*fixed.Int26_6(row%2)
+		for ; x < fixed.I(bounds.Max.X); x += step {
+			drawer.Dot = fixed.Point26_6{X: x, Y: y}
+			drawer.DrawString(opts.BackgroundWatermark)
+		}
+	}
+	draw.DrawMask(img, bounds, layer, bounds.Min, light, image.Point{}, draw.Over)
+	return nil
+}
@@ -0,0 +1,63 @@
+package qrcode
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestGenerateImage_BackgroundWatermark(t *testing.T) {
+	base := Options{
+		Data:       "test",
+		Size:       290,
+		Foreground: "black",
+		Background: "white",
+		Error:      "L",
+		Border:     4,
+	}
+	plain, err := GenerateImage(base)
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+
+	opts := base
+	opts.BackgroundWatermark = "VOID"
+	opts.WatermarkColor = "red"
+	opts.WatermarkOpacity = 0.2
+	img, err := GenerateImage(opts)
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+
+	var tinted int
+	for y := 0; y < 290; y++ {
+		for x := 0; x < 290; x++ {
+			want := color.RGBAModel.Convert(plain.At(x, y)).(color.RGBA)
+			got := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			if want.R == 0 {
+				if got != want {
+					t.Fatalf("dark pixel (%d,%d) = %v, want it untouched", x, y, got)
+				}
+				continue
+			}
+			if got == want {
+				continue
+			}
+			// Red at 20% over white leaves green and blue at 80% or more
+			if got.R != 255 || got.G < 203 || got.B < 203 {
+				t.Fatalf("light pixel (%d,%d) = %v, want a faint red tint", x, y, got)
+			}
+			tinted++
+		}
+	}
+	if tinted == 0 {
+		t.Error("watermark text was not drawn")
+	}
+
+	for _, opacity := range []float64{-0.1, 0.3} {
+		invalid := opts
+		invalid.WatermarkOpacity = opacity
+		if _, err := GenerateImage(invalid); err == nil {
+			t.Errorf("GenerateImage() with watermark opacity %v should fail", opacity)
+		}
+	}
+}